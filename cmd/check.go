@@ -0,0 +1,60 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"dxpm/salesforce"
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the project's declared dependencies for cycles",
+	Long: `Checks the dependencies declared by each packageDirectory in sfdx-project.json
+for dependency cycles without connecting to an org.
+
+Examples:
+
+dxpm check : Must be ran from within an SFDX Project`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		return salesforce.CheckSFDX()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+
+		graph, err := salesforce.ProjectGraph()
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		order, err := graph.InstallOrder()
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("No dependency cycles found in %d packages\n", len(order))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"dxpm/salesforce"
+)
+
+var graphOrg string
+var graphPkg string
+var graphFormat string
+var graphOut string
+
+// graphCmd represents the graph command
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Export the resolved dependency graph",
+	Long: `Resolves the project's dependencies, or a single package, and exports the dependency
+graph as a Graphviz dot digraph, a Mermaid flowchart or JSON. When an org is given each
+package is coloured by whether it is installed, outdated or missing in the org.
+
+Examples:
+
+dxpm graph --format dot --out deps.dot : Must be ran from within an SFDX Project and exports
+the graph of all project dependencies, resolved through your default DevHub
+
+dxpm graph -p <PACKAGE NAME or ID> -o <ORG ID or ALIAS> --format mermaid : Exports the graph
+of the package coloured by its install state in the org`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if _, err := salesforce.ParseGraphFormat(graphFormat); err != nil {
+			return err
+		}
+
+		return nil
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// Keep stdout for the exported graph
+		if len(graphOut) == 0 {
+			salesforce.SetOutput(os.Stderr)
+		}
+
+		return salesforce.CheckSFDX()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		graph, err := salesforce.DependencyGraph(cmd.Context(), graphOrg, graphPkg)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		var states map[*salesforce.Node]salesforce.InstallState
+		if len(graphOrg) > 0 {
+			states, err = salesforce.InstallStates(cmd.Context(), graph, graphOrg)
+			if err != nil {
+				printError(err)
+				os.Exit(1)
+			}
+		}
+
+		if len(graphOut) == 0 {
+			err = salesforce.ExportGraph(os.Stdout, graph, graphFormat, states)
+			if err != nil {
+				printError(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		w, err := os.Create(graphOut)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		err = salesforce.ExportGraph(w, graph, graphFormat, states)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	graphCmd.Flags().StringVarP(&graphOrg, "org", "o", "", "Org Alias or ID to resolve packages through and colour install states from")
+	graphCmd.Flags().StringVarP(&graphPkg, "pkg", "p", "", "Package Alias or ID to export instead of the project dependencies")
+	graphCmd.Flags().StringVar(&graphFormat, "format", salesforce.FormatDot, "Export format, dot, mermaid or json")
+	graphCmd.Flags().StringVar(&graphOut, "out", "", "File to write the graph to instead of stdout")
+
+	rootCmd.AddCommand(graphCmd)
+}
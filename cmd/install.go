@@ -16,7 +16,9 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 
@@ -28,6 +30,10 @@ var pkg string
 var create bool
 var filePath string
 var saveDep bool
+var updateLock bool
+var frozenLock bool
+var installBeta bool
+var installDir string
 
 // installCmd represents the install command
 var installCmd = &cobra.Command{
@@ -39,15 +45,41 @@ can be used to target orgs and packages.
 Examples:
 
 dxpm install -o <ORG ID or ALIAS> : Must be ran from within an SFDX Project and will attempt 
-to install all project dependencies into the specified org
+to install all project dependencies into the specified org. The dependencies of every package
+directory are resolved through packageAliases and their versionNumber (1.2.0.LATEST installs the
+latest build) into one dependency graph, packages already installed are skipped and the rest are
+installed in dependency order
+
+dxpm install -o <ORG ID or ALIAS> --dir <PATH or PACKAGE NAME> : Will install the dependencies
+declared by a single package directory
 
 dxpm install -o <ORG ID or ALIAS> -p <PACKAGE NAME or ID>: Will install the specified package 
 and all dependencies to the target org.
 
+dxpm install -o <ORG ID or ALIAS> -p <PACKAGE NAME or ID>@<VERSION>: Will install the highest 
+version of the package matching the version constraint. Constraints can be an exact version 
+(1.2.3.4), a wildcard (1.2.x), a caret (^1.2), a tilde (~1.4), a range (">=1.2 <2.0") or LATEST.
+Only released versions are installed unless --allow-beta is given for a scratch org or sandbox,
+or beta versions are allowed for the type of org in the beta section of the config file (scratch,
+sandbox or production).
+
+The versions each package resolves to are recorded in dxpm-lock.json next to sfdx-project.json
+and installed again by later installs. Use --update to resolve them again, or --frozen-lockfile
+to fail when the lockfile is missing or would change.
+
+Installed packages are saved as dependencies of the package directory chosen with --dir, or of
+the directory marked default in sfdx-project.json. With --pin, or pin: true in the install section
+of the config file, they are saved with a versionNumber and version aliases as sfdx expects when
+building package versions, see dxpm pin.
+
 dxpm install -p <PACKAGE NAME or ID> -c -f <Path to scratch-def.json> : Will first 
 create a scratch org with the specified alias and then install the package and dependencies`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(org) > 0 && len(pkg) < 0 {
+		if updateLock && frozenLock {
+			return errors.New("--update and --frozen-lockfile cannot be used together")
+		}
+
+		if len(org) > 0 && len(pkg) == 0 {
 			return salesforce.CheckSFDX()
 		}
 
@@ -62,10 +94,35 @@ create a scratch org with the specified alias and then install the package and d
 		orgSet := len(org) > 0
 		pkgSet := len(pkg) > 0
 
+		if installBeta {
+			salesforce.AllowBeta()
+		}
+
+		if updateLock {
+			salesforce.SetLockfileMode(salesforce.LockfileUpdate)
+		}
+
+		if frozenLock {
+			salesforce.SetLockfileMode(salesforce.LockfileFrozen)
+		}
+
+		salesforce.SetPackageDirectory(installDir)
+
 		if orgSet && pkgSet {
-			err := salesforce.InstallPackage(org, pkg)
+			err := salesforce.InstallPackage(cmd.Context(), org, pkg)
 			if err != nil {
-				fmt.Println(err)
+				printInstallError(err)
+				os.Exit(1)
+			}
+
+			return
+		}
+
+		if orgSet {
+			err := salesforce.InstallProjectDependencies(cmd.Context(), org, installDir)
+			if err != nil {
+				printInstallError(err)
+				os.Exit(1)
 			}
 
 			return
@@ -74,6 +131,15 @@ create a scratch org with the specified alias and then install the package and d
 	},
 }
 
+// printInstallError prints the error, pointing at --allow-beta when only beta versions matched.
+func printInstallError(err error) {
+	printError(err)
+
+	if errors.Is(err, salesforce.ErrBetaOnly) {
+		fmt.Println("Use --allow-beta to install beta versions in scratch orgs and sandboxes")
+	}
+}
+
 func init() {
 	installCmd.Flags().StringVarP(&org, "org", "o", "", "Org Alias or ID to install package to")
 	installCmd.MarkFlagRequired("org")
@@ -81,7 +147,17 @@ func init() {
 	installCmd.Flags().StringVarP(&pkg, "pkg", "p", "", "Package Alias or ID to install")
 	installCmd.Flags().BoolVarP(&create, "create", "c", false, "Creates a new scratch org from file")
 	installCmd.Flags().StringVarP(&filePath, "file", "f", "", "Scratch Org Definition File Path")
+	installCmd.Flags().StringVar(&installDir, "dir", "", "Package directory, by path or package name, to install the dependencies of and save packages to")
 	installCmd.Flags().BoolVarP(&saveDep, "save", "s", false, "Attempts to save package as a dependency to sfdx-project.json")
+	installCmd.Flags().Duration("poll-interval", 0, "How often to check the status of each package install (default 10s)")
+	installCmd.Flags().Duration("wait", 0, "How long to wait for each package install to finish (default 30m)")
+	installCmd.Flags().Int("retries", 0, "Attempts made at each package install before giving up (default 4)")
+	installCmd.Flags().Duration("retry-backoff", 0, "Delay before retrying a failed package install, doubled after each retry (default 10s)")
+	installCmd.Flags().BoolVar(&updateLock, "update", false, "Resolve packages again instead of installing the versions locked in dxpm-lock.json")
+	installCmd.Flags().BoolVar(&frozenLock, "frozen-lockfile", false, "Fail if dxpm-lock.json is missing or would change, for CI")
+	installCmd.Flags().BoolVar(&installBeta, "allow-beta", false, "Allow package names to resolve to beta versions in scratch orgs and sandboxes, which cannot be upgraded")
+	installCmd.Flags().Bool("pin", false, "Save packages with a versionNumber and version aliases instead of a floating alias")
+	installCmd.Flags().String("strategy", "", "How conflicting dependency versions are resolved, highest or fail (default highest)")
 
 	rootCmd.AddCommand(installCmd)
 
@@ -48,9 +48,9 @@ var orgCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if devHub {
-			dev, err := salesforce.DevHub()
+			dev, err := salesforce.DevHub(cmd.Context())
 			if err != nil {
-				fmt.Println(err)
+				printError(err)
 				return
 			}
 			fmt.Printf("Org ID:   %s\n", dev.OrgID)
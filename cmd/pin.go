@@ -0,0 +1,71 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"dxpm/salesforce"
+)
+
+var pinOrg string
+var pinDir string
+var pinPkg string
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:   "pin",
+	Short: "Pin project dependencies to their versions",
+	Long: `Converts the project's dependencies to their pinned form, the form sfdx needs to build
+package versions. Dependencies on dev hub packages are declared by name with a versionNumber, the
+exact build for managed packages and the LATEST build for unlocked packages, with a Name alias naming
+the 0Ho package and a Name@Major.Minor.Patch-Build alias naming the 04t version. Dependencies on
+packages from outside the dev hub are declared by their Name@Major.Minor.Patch-Build alias.
+
+Dependencies are pinned to the versions locked in dxpm-lock.json, or otherwise resolved again.
+Package directories share the Name alias, so pinning a package in one directory with --dir fails
+while another directory still declares it without a versionNumber.
+
+Examples:
+
+dxpm pin : Must be ran from within an SFDX Project and pins every dependency, resolving packages
+through your default DevHub
+
+dxpm pin --dir <PATH or PACKAGE NAME> -p <PACKAGE NAME or ID> : Pins only the dependency of one
+package directory on one package
+
+dxpm pin -o <ORG ID or ALIAS> : Resolves packages through the org, for packages from outside the dev hub`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		return salesforce.CheckSFDX()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := salesforce.PinDependencies(cmd.Context(), pinOrg, pinDir, pinPkg)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	pinCmd.Flags().StringVarP(&pinOrg, "org", "o", "", "Org Alias or ID to resolve packages through (default is your DevHub)")
+	pinCmd.Flags().StringVar(&pinDir, "dir", "", "Package directory, by path or package name, to pin the dependencies of")
+	pinCmd.Flags().StringVarP(&pinPkg, "pkg", "p", "", "Package Name or ID to pin the dependency on")
+
+	rootCmd.AddCommand(pinCmd)
+}
@@ -16,33 +16,95 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 
 	"github.com/spf13/cobra"
 
+	"dxpm/salesforce"
+
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
 
 var cfgFile string
+var recordDir string
+var replayDir string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "dxpm",
 	Short: "SFDX Package Manager",
 	Long:  `CLI Tool for managing the installation and dependencies of SalesForce DX packages.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		bindConfigFlags(cmd)
+		initTimeouts()
+		initRetry()
+		initResolve()
+		initBeta()
+		initPin()
+	},
+}
+
+// configFlags maps command flags to the config settings they override.
+var configFlags = map[string]string{
+	"poll-interval": "install.pollInterval",
+	"wait":          "timeouts.install",
+	"retries":       "retry.maxAttempts",
+	"retry-backoff": "retry.backoff",
+	"strategy":      "resolve.strategy",
+	"pin":           "install.pin",
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel running commands on Ctrl-C rather than killing dxpm mid install
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		fmt.Println("\nInterrupted, cancelling...")
+		cancel()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+
+	if ctx.Err() != nil {
+		printProgress()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		printError(err)
 		os.Exit(1)
 	}
 }
 
+// printProgress prints the packages installed before dxpm was interrupted and those that were not.
+func printProgress() {
+	completed, pending := salesforce.InstallProgress()
+	if len(completed) == 0 && len(pending) == 0 {
+		return
+	}
+
+	fmt.Println("Completed:")
+	for _, pkg := range completed {
+		fmt.Printf("  %s\n", pkg)
+	}
+
+	fmt.Println("Pending:")
+	for _, pkg := range pending {
+		fmt.Printf("  %s\n", pkg)
+	}
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
@@ -51,12 +113,32 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.dxpm.yaml)")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "Record sfdx invocations and their output, with access tokens, instance URLs and usernames redacted, to fixtures in this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "Replay sfdx invocations from fixtures in this directory instead of running sfdx")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
+// printError prints the error along with any actions the Salesforce CLI suggests to resolve it.
+func printError(err error) {
+	var sfErr *salesforce.SfdxError
+	if !errors.As(err, &sfErr) {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("ERROR: %s\n", sfErr.Message)
+
+	if len(sfErr.Actions) > 0 {
+		fmt.Println("Try this:")
+		for _, action := range sfErr.Actions {
+			fmt.Printf("  %s\n", action)
+		}
+	}
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 
@@ -80,9 +162,11 @@ func initConfig() {
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 
+	initRunner()
+
 	cache := home + "/.dxpm"
 
 	_, err = os.Stat(cache)
@@ -90,3 +174,133 @@ func initConfig() {
 		os.Mkdir(cache, 0777)
 	}
 }
+
+// initRunner swaps the sfdx runner for a recording or replaying one when requested.
+func initRunner() {
+	if len(recordDir) > 0 && len(replayDir) > 0 {
+		fmt.Println("--record and --replay cannot be used together")
+		os.Exit(1)
+	}
+
+	// The cli setting forces sf or sfdx, otherwise sf is preferred when found on PATH
+	execRunner := &salesforce.ExecRunner{Executable: viper.GetString("cli")}
+	salesforce.SetRunner(execRunner)
+
+	// Queries are sent straight to the org's apis unless disabled. Recorded and
+	// replayed sessions always query through the cli so every query is a fixture.
+	viper.SetDefault("api", true)
+	salesforce.EnableAPI(viper.GetBool("api") && len(recordDir) == 0 && len(replayDir) == 0)
+
+	if len(recordDir) > 0 {
+		r, err := salesforce.NewRecordingRunner(execRunner, recordDir)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		salesforce.SetRunner(r)
+	}
+
+	if len(replayDir) > 0 {
+		r, err := salesforce.NewReplayRunner(replayDir)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		salesforce.SetRunner(r)
+	}
+}
+
+// initTimeouts applies the per operation timeouts from the timeouts config section
+// and the package install poll interval.
+func initTimeouts() {
+	viper.SetDefault("timeouts.install", "30m")
+	viper.SetDefault("timeouts.uninstall", "30m")
+	viper.SetDefault("timeouts.query", "2m")
+	viper.SetDefault("timeouts.list", "2m")
+
+	viper.SetDefault("install.pollInterval", "10s")
+	salesforce.SetPollInterval(viper.GetDuration("install.pollInterval"))
+
+	salesforce.SetTimeouts(salesforce.Timeouts{
+		Install:   viper.GetDuration("timeouts.install"),
+		Uninstall: viper.GetDuration("timeouts.uninstall"),
+		Query:     viper.GetDuration("timeouts.query"),
+		List:      viper.GetDuration("timeouts.list"),
+	})
+}
+
+// bindConfigFlags lets the flags of the command being run override their config settings.
+func bindConfigFlags(cmd *cobra.Command) {
+	for flag, key := range configFlags {
+		if f := cmd.Flags().Lookup(flag); f != nil {
+			viper.BindPFlag(key, f)
+		}
+	}
+}
+
+// initRetry applies the retry policy from the retry config section.
+func initRetry() {
+	viper.SetDefault("retry.maxAttempts", 4)
+	viper.SetDefault("retry.backoff", "10s")
+	viper.SetDefault("retry.maxBackoff", "2m")
+	viper.SetDefault("retry.jitter", 0.2)
+	viper.SetDefault("retry.retryOn", []string{"unavailable", "lock", "concurrent"})
+
+	retryable, err := salesforce.ParseRetryClasses(viper.GetStringSlice("retry.retryOn"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	salesforce.SetRetryPolicy(salesforce.RetryPolicy{
+		MaxAttempts: viper.GetInt("retry.maxAttempts"),
+		Backoff:     viper.GetDuration("retry.backoff"),
+		MaxBackoff:  viper.GetDuration("retry.maxBackoff"),
+		Jitter:      viper.GetFloat64("retry.jitter"),
+		Retryable:   retryable,
+	})
+}
+
+// initResolve applies the version conflict strategy and resolution sources from the resolve config section.
+func initResolve() {
+	viper.SetDefault("resolve.strategy", string(salesforce.StrategyHighest))
+	viper.SetDefault("resolve.sources", []string{"aliases", "devhub", "org"})
+
+	strategy, err := salesforce.ParseConflictStrategy(viper.GetString("resolve.strategy"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	salesforce.SetConflictStrategy(strategy)
+
+	sources, err := salesforce.ParseSources(viper.GetStringSlice("resolve.sources"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	salesforce.SetSources(sources)
+}
+
+// initBeta applies which types of org package names may resolve to beta versions for from the beta config section.
+func initBeta() {
+	viper.SetDefault("beta.scratch", false)
+	viper.SetDefault("beta.sandbox", false)
+	viper.SetDefault("beta.production", false)
+
+	salesforce.SetBetaPolicy(salesforce.BetaPolicy{
+		Scratch:    viper.GetBool("beta.scratch"),
+		Sandbox:    viper.GetBool("beta.sandbox"),
+		Production: viper.GetBool("beta.production"),
+	})
+}
+
+// initPin applies whether installed packages are saved to sfdx-project.json in their pinned form.
+func initPin() {
+	viper.SetDefault("install.pin", false)
+
+	salesforce.SetPinDependencies(viper.GetBool("install.pin"))
+}
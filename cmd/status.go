@@ -0,0 +1,56 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"dxpm/salesforce"
+)
+
+var statusOrg string
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status <REQUEST ID>",
+	Short: "Re-attach to a package install request",
+	Long: `Checks on a package install request (0Hf) started by dxpm install and waits
+for it to finish, printing its progress.
+
+Examples:
+
+dxpm status <REQUEST ID> -o <ORG ID or ALIAS> : Waits for the install request in the target org`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		req, err := salesforce.WaitForInstall(cmd.Context(), statusOrg, args[0])
+		if err != nil {
+			printError(err)
+			return
+		}
+
+		fmt.Printf("Package %s installed\n", req.SubscriberPackageVersionKey)
+	},
+}
+
+func init() {
+	statusCmd.Flags().StringVarP(&statusOrg, "org", "o", "", "Org Alias or ID the package is being installed to")
+	statusCmd.MarkFlagRequired("org")
+
+	rootCmd.AddCommand(statusCmd)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"dxpm/salesforce"
+)
+
+var treeOrg string
+var treePkg string
+var treeDepth int
+var treeJSON bool
+
+// treeCmd represents the tree command
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Print the resolved dependency tree",
+	Long: `Resolves the project's dependencies, or a single package, and prints the dependency
+tree install would walk without installing anything. Packages repeated in the tree are
+marked (deduped) and their dependencies are only listed where they first appear.
+
+Examples:
+
+dxpm tree : Must be ran from within an SFDX Project and prints the tree of all project
+dependencies, resolved through your default DevHub
+
+dxpm tree -p <PACKAGE NAME or ID> -o <ORG ID or ALIAS> : Prints the tree of the package
+marking which versions are installed in the org
+
+dxpm tree --depth 1 --json : Prints the project dependencies and their direct dependencies as JSON`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// Keep stdout for the JSON tree
+		if treeJSON {
+			salesforce.SetOutput(os.Stderr)
+		}
+
+		return salesforce.CheckSFDX()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		graph, err := salesforce.DependencyGraph(cmd.Context(), treeOrg, treePkg)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		tree, err := salesforce.DependencyTree(cmd.Context(), graph, treeOrg, treeDepth)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		if treeJSON {
+			bytes, err := json.MarshalIndent(tree, "", "  ")
+			if err != nil {
+				printError(err)
+				os.Exit(1)
+			}
+
+			fmt.Println(string(bytes))
+			return
+		}
+
+		for _, root := range tree {
+			fmt.Println(treeLabel(root))
+			printTree(root.Dependencies, "")
+		}
+	},
+}
+
+// printTree prints each node below its parent with box drawing branches.
+func printTree(nodes []*salesforce.TreeNode, indent string) {
+	for i, n := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		fmt.Println(indent + branch + treeLabel(n))
+		printTree(n.Dependencies, indent+next)
+	}
+}
+
+// treeLabel describes the node's package version and its installed state.
+func treeLabel(n *salesforce.TreeNode) string {
+	label := fmt.Sprintf("%s %s - %s", n.Name, n.Version, n.ID)
+
+	if n.Installed != nil {
+		switch {
+		case *n.Installed:
+			label += " [installed]"
+		case len(n.InstalledVersion) > 0:
+			label += fmt.Sprintf(" [installed %s]", n.InstalledVersion)
+		default:
+			label += " [not installed]"
+		}
+	}
+
+	if n.Deduped {
+		label += " (deduped)"
+	}
+
+	return label
+}
+
+func init() {
+	treeCmd.Flags().StringVarP(&treeOrg, "org", "o", "", "Org Alias or ID to resolve packages through and check installed versions in")
+	treeCmd.Flags().StringVarP(&treePkg, "pkg", "p", "", "Package Alias or ID to print instead of the project dependencies")
+	treeCmd.Flags().IntVar(&treeDepth, "depth", 0, "Levels of dependencies to print below each package, 0 for all")
+	treeCmd.Flags().BoolVar(&treeJSON, "json", false, "Print the tree as JSON")
+
+	rootCmd.AddCommand(treeCmd)
+}
@@ -16,13 +16,13 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 
 	"dxpm/salesforce"
 )
 
+var uninstallDir string
+
 // uninstallCmd represents the install command
 var uninstallCmd = &cobra.Command{
 	Use:   "uninstall",
@@ -36,7 +36,10 @@ dxpm uninstall -o <ORG ID or ALIAS> : Must be ran from within an SFDX Project an
 to install all project dependencies into the specified org
 
 dxpm uninstall -o <ORG ID or ALIAS> -p <PACKAGE NAME or ID>: Will uninstall the specified package 
-and all dependencies to the target org.`,
+and all dependencies to the target org.
+
+The package is removed from the dependencies of the package directory chosen with --dir, by path
+or package name, or of the directory marked default in sfdx-project.json.`,
 
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(org) > 0 && len(pkg) < 0 {
@@ -54,10 +57,12 @@ and all dependencies to the target org.`,
 		orgSet := len(org) > 0
 		pkgSet := len(pkg) > 0
 
+		salesforce.SetPackageDirectory(uninstallDir)
+
 		if orgSet && pkgSet {
-			err := salesforce.UninstallPackage(org, pkg)
+			err := salesforce.UninstallPackage(cmd.Context(), org, pkg)
 			if err != nil {
-				fmt.Println(err)
+				printError(err)
 			}
 
 			return
@@ -71,6 +76,9 @@ func init() {
 	uninstallCmd.MarkFlagRequired("org")
 
 	uninstallCmd.Flags().StringVarP(&pkg, "pkg", "p", "", "Package Alias or ID to uninstall")
+	uninstallCmd.Flags().StringVar(&uninstallDir, "dir", "", "Package directory, by path or package name, to remove the package from")
+	uninstallCmd.Flags().Int("retries", 0, "Attempts made at the package uninstall before giving up (default 4)")
+	uninstallCmd.Flags().Duration("retry-backoff", 0, "Delay before retrying a failed package uninstall, doubled after each retry (default 10s)")
 
 	rootCmd.AddCommand(uninstallCmd)
 
@@ -0,0 +1,58 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"dxpm/salesforce"
+)
+
+// unpinCmd represents the unpin command
+var unpinCmd = &cobra.Command{
+	Use:   "unpin",
+	Short: "Convert pinned project dependencies to floating aliases",
+	Long: `Converts the project's dependencies to their floating form, declared by name with a Name
+alias naming the 04t version locked in dxpm-lock.json, or otherwise resolved again. Version aliases
+no dependency references anymore are removed.
+
+Examples:
+
+dxpm unpin : Must be ran from within an SFDX Project and unpins every dependency
+
+dxpm unpin --dir <PATH or PACKAGE NAME> -p <PACKAGE NAME or ID> : Unpins only the dependency of one
+package directory on one package`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		return salesforce.CheckSFDX()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := salesforce.UnpinDependencies(cmd.Context(), pinOrg, pinDir, pinPkg)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	unpinCmd.Flags().StringVarP(&pinOrg, "org", "o", "", "Org Alias or ID to resolve packages through (default is your DevHub)")
+	unpinCmd.Flags().StringVar(&pinDir, "dir", "", "Package directory, by path or package name, to unpin the dependencies of")
+	unpinCmd.Flags().StringVarP(&pinPkg, "pkg", "p", "", "Package Name or ID to unpin the dependency on")
+
+	rootCmd.AddCommand(unpinCmd)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"dxpm/salesforce"
+)
+
+var validateJSON bool
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check sfdx-project.json for inconsistencies",
+	Long: `Checks that sfdx-project.json is coherent, reporting:
+
+  missing-alias    dependencies without a packageAliases entry
+  unused-alias     aliases no dependency or packageDirectory references (warning)
+  alias-type       aliases naming a 0Ho package where a 04t version is expected, or the reverse
+  duplicate-path   packageDirectories declared more than once
+  cycle            dependencies forming a cycle across packageDirectories
+  missing-path     packageDirectory paths missing on disk
+  unknown-version  dependency versionNumbers which do not exist in your default DevHub
+
+Exits with a non-zero status when any errors are found, for CI.
+
+Examples:
+
+dxpm validate : Must be ran from within an SFDX Project
+
+dxpm validate --json : Prints the issues found as JSON`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// Keep stdout for the JSON report
+		if validateJSON {
+			salesforce.SetOutput(os.Stderr)
+		}
+
+		return salesforce.CheckSFDX()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		validation, err := salesforce.ValidateProject(cmd.Context())
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		if validateJSON {
+			bytes, err := json.MarshalIndent(validation, "", "  ")
+			if err != nil {
+				printError(err)
+				os.Exit(1)
+			}
+
+			fmt.Println(string(bytes))
+		} else {
+			for _, issue := range validation.Issues {
+				fmt.Printf("%-7s %-15s %s\n", strings.ToUpper(string(issue.Severity)), issue.Check, issue.Message)
+			}
+
+			fmt.Printf("%d errors, %d warnings\n", validation.Errors(), validation.Warnings())
+		}
+
+		if validation.Errors() > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	validateCmd.Flags().BoolVar(&validateJSON, "json", false, "Print the issues found as JSON")
+
+	rootCmd.AddCommand(validateCmd)
+}
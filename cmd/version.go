@@ -16,10 +16,6 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
-	"os"
-	"os/exec"
-
 	"dxpm/salesforce"
 
 	"github.com/spf13/cobra"
@@ -28,21 +24,19 @@ import (
 // versionCmd represents the version command
 var versionCmd = &cobra.Command{
 	Use:   "version",
-	Short: "displays the version of dxpm and sfdx",
-	Long:  `displays the version of dxpm and sfdx`,
+	Short: "displays the version of dxpm and the Salesforce CLI",
+	Long:  `displays the version of dxpm and the Salesforce CLI (sf or sfdx)`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		err := salesforce.CheckCli()
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
 			return
 		}
 
-		sfdx := exec.Command("sfdx", "-v")
-		sfdx.Stdout = os.Stdout
-		err = sfdx.Run()
+		err = salesforce.CliVersion(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
+			printError(err)
 		}
 	},
 }
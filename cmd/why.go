@@ -0,0 +1,119 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"dxpm/salesforce"
+)
+
+var whyOrg string
+
+// whyCmd represents the why command
+var whyCmd = &cobra.Command{
+	Use:   "why <PACKAGE NAME or ID>",
+	Short: "Explain why a package is required",
+	Long: `Resolves the project's dependencies and prints every dependency path from a project
+dependency down to the package, given by name, 0Ho package ID or 04t version ID.
+
+Examples:
+
+dxpm why <PACKAGE NAME or ID> : Must be ran from within an SFDX Project and resolves
+packages through your default DevHub
+
+dxpm why <PACKAGE NAME or ID> -o <ORG ID or ALIAS> : Also explains which packages installed
+in the org require the package, including packages no project dependency requires`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Exactly one package must be specified")
+		}
+
+		return salesforce.CheckSFDX()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ref := args[0]
+
+		match, err := salesforce.PackageMatcher(cmd.Context(), ref)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		graph, err := salesforce.DependencyGraph(cmd.Context(), whyOrg, "")
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		paths := graph.Paths(match)
+		if len(paths) == 0 {
+			fmt.Printf("No project dependency requires %s\n", ref)
+		} else {
+			fmt.Printf("%s is required by the project through:\n", ref)
+			printPaths(paths, "project dependency")
+		}
+
+		if len(whyOrg) == 0 {
+			return
+		}
+
+		installed, err := salesforce.InstalledGraph(cmd.Context(), whyOrg)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+
+		paths = installed.Paths(match)
+		if len(paths) == 0 {
+			fmt.Printf("%s is not installed in %s\n", ref, whyOrg)
+			return
+		}
+
+		fmt.Printf("%s is required in %s through:\n", ref, whyOrg)
+		printPaths(paths, "installed, not required by any installed package")
+	},
+}
+
+// printPaths prints each dependency path on its own line, noting why paths that are only the package itself exist.
+func printPaths(paths [][]*salesforce.Node, direct string) {
+	for _, path := range paths {
+		names := make([]string, 0, len(path))
+		for _, n := range path {
+			names = append(names, n.String())
+		}
+
+		line := strings.Join(names, " > ")
+		if len(path) == 1 {
+			line += fmt.Sprintf(" - %s (%s)", path[0].ID, direct)
+		} else {
+			line += " - " + path[len(path)-1].ID
+		}
+
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+func init() {
+	whyCmd.Flags().StringVarP(&whyOrg, "org", "o", "", "Org Alias or ID to resolve packages through and explain installed packages in")
+
+	rootCmd.AddCommand(whyCmd)
+}
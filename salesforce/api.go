@@ -0,0 +1,182 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultAPIVersion = "50.0"
+
+var apiEnabled = true
+
+// EnableAPI controls whether queries are sent directly to the Salesforce
+// APIs using the org's access token. When disabled every query goes through the CLI.
+func EnableAPI(enabled bool) {
+	apiEnabled = enabled
+}
+
+// Client queries the Salesforce REST and Tooling APIs of an org.
+type Client struct {
+	InstanceURL string
+	AccessToken string
+	APIVersion  string
+	HTTPClient  *http.Client
+}
+
+// NewClient returns a Client for the org at instanceURL authenticated with accessToken.
+func NewClient(instanceURL string, accessToken string, apiVersion string) *Client {
+	if len(apiVersion) == 0 {
+		apiVersion = defaultAPIVersion
+	}
+
+	return &Client{
+		InstanceURL: strings.TrimSuffix(instanceURL, "/"),
+		AccessToken: accessToken,
+		APIVersion:  apiVersion,
+		HTTPClient:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// APIError represents an error response from the Salesforce APIs.
+type APIError struct {
+	StatusCode int
+	ErrorCode  string `json:"errorCode"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if len(e.ErrorCode) > 0 {
+		return fmt.Sprintf("%s: %s", e.ErrorCode, e.Message)
+	}
+
+	return fmt.Sprintf("Salesforce API request failed with status %d", e.StatusCode)
+}
+
+type apiQueryResponse struct {
+	TotalSize      int
+	Done           bool
+	NextRecordsURL string `json:"nextRecordsUrl"`
+	Records        []json.RawMessage
+}
+
+// Query runs the SOQL query against the REST api and returns every record,
+// following nextRecordsUrl until the result is done.
+func (c *Client) Query(ctx context.Context, soql string) ([]json.RawMessage, error) {
+	return c.query(ctx, "query", soql)
+}
+
+// ToolingQuery runs the SOQL query against the Tooling api and returns every record,
+// following nextRecordsUrl until the result is done.
+func (c *Client) ToolingQuery(ctx context.Context, soql string) ([]json.RawMessage, error) {
+	return c.query(ctx, "tooling/query", soql)
+}
+
+func (c *Client) query(ctx context.Context, resource string, soql string) ([]json.RawMessage, error) {
+	path := fmt.Sprintf("/services/data/v%s/%s/?q=%s", c.APIVersion, resource, url.QueryEscape(soql))
+
+	var records []json.RawMessage
+	for len(path) > 0 {
+		var resp apiQueryResponse
+		if err := c.get(ctx, path, &resp); err != nil {
+			return nil, err
+		}
+
+		records = append(records, resp.Records...)
+
+		path = ""
+		if !resp.Done {
+			path = resp.NextRecordsURL
+		}
+	}
+
+	return records, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.InstanceURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeAPIError(resp.StatusCode, body)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+func decodeAPIError(status int, body []byte) error {
+	var errs []APIError
+	if err := json.Unmarshal(body, &errs); err == nil && len(errs) > 0 {
+		errs[0].StatusCode = status
+		return &errs[0]
+	}
+
+	return &APIError{StatusCode: status}
+}
+
+// apiClient returns a Client for the org with the given username, or nil
+// when the API is disabled or the org list has no access token for it.
+func apiClient(ctx context.Context, userName string) *Client {
+	if !apiEnabled {
+		return nil
+	}
+
+	if err := getOrgs(ctx); err != nil {
+		return nil
+	}
+
+	instanceURL, accessToken := "", ""
+	for _, org := range orgs {
+		if org.UserName == userName {
+			instanceURL, accessToken = org.InstanceURL, org.AccessToken
+		}
+	}
+
+	for _, org := range scrOrgs {
+		if org.UserName == userName {
+			instanceURL, accessToken = org.InstanceURL, org.AccessToken
+		}
+	}
+
+	if len(instanceURL) == 0 || len(accessToken) == 0 {
+		return nil
+	}
+
+	return NewClient(instanceURL, accessToken, apiVersion())
+}
+
+// apiVersion returns the project's sourceApiVersion when a project
+// has been located, otherwise the default api version.
+func apiVersion() string {
+	if len(projectPath) == 0 {
+		return defaultAPIVersion
+	}
+
+	proj, err := readProject()
+	if err != nil || len(proj.SourceAPIVersion) == 0 {
+		return defaultAPIVersion
+	}
+
+	return proj.SourceAPIVersion
+}
@@ -0,0 +1,166 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientQueryPaging(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q, want Bearer token", got)
+		}
+
+		switch r.URL.Path {
+		case "/services/data/v58.0/tooling/query/":
+			if got := r.URL.Query().Get("q"); got != "SELECT Id FROM Package2" {
+				t.Errorf("q = %q, want SELECT Id FROM Package2", got)
+			}
+			fmt.Fprint(w, `{"totalSize":3,"done":false,"nextRecordsUrl":"/services/data/v58.0/tooling/query/01g-2000","records":[{"Id":"0Ho1"},{"Id":"0Ho2"}]}`)
+		case "/services/data/v58.0/tooling/query/01g-2000":
+			fmt.Fprint(w, `{"totalSize":3,"done":true,"records":[{"Id":"0Ho3"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	records, err := NewClient(server.URL+"/", "token", "58.0").ToolingQuery(context.Background(), "SELECT Id FROM Package2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, record := range records {
+		ids = append(ids, string(record))
+	}
+
+	if got, want := strings.Join(ids, ","), `{"Id":"0Ho1"},{"Id":"0Ho2"},{"Id":"0Ho3"}`; got != want {
+		t.Errorf("records = %s, want %s", got, want)
+	}
+
+	if len(paths) != 2 {
+		t.Errorf("requested %v, want two pages", paths)
+	}
+}
+
+func TestClientAPIError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   APIError
+		text   string
+	}{
+		{
+			name:   "error list",
+			status: http.StatusBadRequest,
+			body:   `[{"message":"unexpected token: FORM","errorCode":"MALFORMED_QUERY"}]`,
+			want:   APIError{StatusCode: http.StatusBadRequest, ErrorCode: "MALFORMED_QUERY", Message: "unexpected token: FORM"},
+			text:   "MALFORMED_QUERY: unexpected token: FORM",
+		},
+		{
+			name:   "expired session",
+			status: http.StatusUnauthorized,
+			body:   `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`,
+			want:   APIError{StatusCode: http.StatusUnauthorized, ErrorCode: "INVALID_SESSION_ID", Message: "Session expired or invalid"},
+			text:   "INVALID_SESSION_ID: Session expired or invalid",
+		},
+		{
+			name:   "no error body",
+			status: http.StatusServiceUnavailable,
+			body:   `<html>Service Unavailable</html>`,
+			want:   APIError{StatusCode: http.StatusServiceUnavailable},
+			text:   "Salesforce API request failed with status 503",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			_, err := NewClient(server.URL, "token", "").Query(context.Background(), "SELECT Id FROM Organization")
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Query() error = %v, want an APIError", err)
+			}
+
+			if *apiErr != tt.want {
+				t.Errorf("Query() error = %+v, want %+v", *apiErr, tt.want)
+			}
+
+			if apiErr.Error() != tt.text {
+				t.Errorf("Error() = %q, want %q", apiErr.Error(), tt.text)
+			}
+		})
+	}
+}
+
+// apiTestRunner serves an org list naming the org at instanceURL with an access token,
+// and the CLI result of the query, so tests can tell which path answered it.
+func apiTestRunner(instanceURL string, soql string) *stubRunner {
+	return &stubRunner{outputs: map[string]string{
+		"org list": `{"status":0,"result":{"nonScratchOrgs":[{"username":"user1@example.com","alias":"dev","accessToken":"token","instanceUrl":"` + instanceURL + `"}],"scratchOrgs":[]}}`,
+		"data query --target-org user1@example.com --use-tooling-api --query " + soql: `{"status":0,"result":{"done":true,"totalSize":1,"records":[{"Id":"cli"}]}}`,
+	}}
+}
+
+func TestToolingQueryFallback(t *testing.T) {
+	const soql = "SELECT Id FROM SubscriberPackage"
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "api", status: http.StatusOK, body: `{"done":true,"totalSize":1,"records":[{"Id":"api"}]}`, want: `{"Id":"api"}`},
+		{name: "expired token falls back to the cli", status: http.StatusUnauthorized, body: `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`, want: `{"Id":"cli"}`},
+		{name: "other errors are returned", status: http.StatusBadRequest, body: `[{"message":"unexpected token","errorCode":"MALFORMED_QUERY"}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			SetRunner(apiTestRunner(server.URL, soql))
+			SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+			EnableAPI(true)
+			defer SetRunner(&ExecRunner{})
+
+			records, err := toolingQuery(context.Background(), "user1@example.com", soql)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("toolingQuery() = %s, want an error", records)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != 1 || string(records[0]) != tt.want {
+				t.Errorf("toolingQuery() = %s, want [%s]", records, tt.want)
+			}
+		})
+	}
+}
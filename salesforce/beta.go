@@ -0,0 +1,77 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBetaOnly is returned when only beta versions of a package match its reference
+// and beta versions are not allowed for the target org.
+var ErrBetaOnly = errors.New("Only beta versions found")
+
+// Org types beta versions can be allowed for
+const (
+	OrgTypeScratch    = "scratch"
+	OrgTypeSandbox    = "sandbox"
+	OrgTypeProduction = "production"
+)
+
+// BetaPolicy decides, for each type of org, whether package names may resolve to beta versions.
+// Beta versions cannot be installed in production orgs and cannot be upgraded once installed.
+type BetaPolicy struct {
+	Scratch    bool
+	Sandbox    bool
+	Production bool
+}
+
+var betaPolicy BetaPolicy
+
+// SetBetaPolicy sets which types of org package names may resolve to beta versions for.
+func SetBetaPolicy(p BetaPolicy) {
+	betaPolicy = p
+}
+
+// AllowBeta lets package names resolve to beta versions in scratch orgs and sandboxes. Production
+// orgs keep the policy they were given, beta versions installed there can never be upgraded.
+func AllowBeta() {
+	betaPolicy.Scratch = true
+	betaPolicy.Sandbox = true
+}
+
+// allowBeta reports whether package names may resolve to beta versions for the org.
+func allowBeta(ctx context.Context, org string) (bool, error) {
+	orgType, err := getOrgType(ctx, org)
+	if err != nil {
+		return false, err
+	}
+
+	switch orgType {
+	case OrgTypeScratch:
+		return betaPolicy.Scratch, nil
+	case OrgTypeSandbox:
+		return betaPolicy.Sandbox, nil
+	default:
+		return betaPolicy.Production, nil
+	}
+}
+
+// getOrgType returns whether the org, by username, is a scratch org, a sandbox or a production org.
+func getOrgType(ctx context.Context, userName string) (string, error) {
+	if err := getOrgs(ctx); err != nil {
+		return "", err
+	}
+
+	for _, org := range scrOrgs {
+		if org.UserName == userName {
+			return OrgTypeScratch, nil
+		}
+	}
+
+	for _, org := range orgs {
+		if org.UserName == userName && org.IsSandbox {
+			return OrgTypeSandbox, nil
+		}
+	}
+
+	return OrgTypeProduction, nil
+}
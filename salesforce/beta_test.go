@@ -0,0 +1,112 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// useTestOrgs lists a production org, a sandbox and a scratch org instead of asking the cli.
+func useTestOrgs(t *testing.T) {
+	t.Helper()
+
+	orgs = []Org{{UserName: "admin@example.com"}, {UserName: "admin@example.com.uat", IsSandbox: true}}
+	scrOrgs = []ScratchOrg{{UserName: "test-1@example.com"}}
+	t.Cleanup(func() {
+		orgs, scrOrgs = nil, nil
+		SetBetaPolicy(BetaPolicy{})
+	})
+}
+
+func TestAllowBeta(t *testing.T) {
+	useTestOrgs(t)
+
+	tests := []struct {
+		name       string
+		policy     BetaPolicy
+		allowBeta  bool
+		scratch    bool
+		sandbox    bool
+		production bool
+	}{
+		{name: "default"},
+		{name: "--allow-beta", allowBeta: true, scratch: true, sandbox: true},
+		{name: "config", policy: BetaPolicy{Sandbox: true}, sandbox: true},
+		{name: "config and --allow-beta", policy: BetaPolicy{Production: true}, allowBeta: true, scratch: true, sandbox: true, production: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetBetaPolicy(tt.policy)
+			if tt.allowBeta {
+				AllowBeta()
+			}
+
+			for org, want := range map[string]bool{
+				"test-1@example.com":    tt.scratch,
+				"admin@example.com.uat": tt.sandbox,
+				"admin@example.com":     tt.production,
+				"other@example.com":     tt.production,
+			} {
+				got, err := allowBeta(context.Background(), org)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got != want {
+					t.Errorf("allowBeta(%s) = %t, want %t", org, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectPkgVersion(t *testing.T) {
+	versions := []PkgVersion{
+		{ID: "04t000000000001AAA", Version: "1.0.0.1", IsReleased: true},
+		{ID: "04t000000000002AAA", Version: "1.1.0.1"},
+		{ID: "04t000000000003AAA", Version: "1.0.0.2", IsReleased: true},
+		{ID: "04t000000000004AAA", Version: "2.0.0.1"},
+	}
+
+	tests := []struct {
+		constraint string
+		allowBeta  bool
+		want       string
+		err        error
+		message    string
+	}{
+		{constraint: "LATEST", want: "04t000000000003AAA"},
+		{constraint: "LATEST", allowBeta: true, want: "04t000000000004AAA"},
+		{constraint: "1.x", allowBeta: true, want: "04t000000000002AAA"},
+		{constraint: "1.0.0.1", want: "04t000000000001AAA"},
+		{constraint: "^2", err: ErrBetaOnly, message: "beta versions: 2.0.0.1"},
+		{constraint: ">=1.1", err: ErrBetaOnly, message: "beta versions: 1.1.0.1, 2.0.0.1"},
+		{constraint: "^3", allowBeta: true, err: ErrPackageNotFound, message: "available versions: 1.0.0.1, 1.0.0.2, 1.1.0.1, 2.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ver, err := selectPkgVersion(versions, c, tt.allowBeta)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("selectPkgVersion(%s, %t) error = %v, want %v listing %q", tt.constraint, tt.allowBeta, err, tt.err, tt.message)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("selectPkgVersion(%s, %t) error = %v", tt.constraint, tt.allowBeta, err)
+			continue
+		}
+
+		if ver.ID != tt.want {
+			t.Errorf("selectPkgVersion(%s, %t) = %s, want %s", tt.constraint, tt.allowBeta, ver.ID, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package salesforce
+
+const (
+	cliSf   = "sf"
+	cliSfdx = "sfdx"
+)
+
+// commandSet maps each operation dxpm performs to the
+// command and flags understood by a Salesforce CLI.
+type commandSet struct {
+	version                []string
+	orgList                []string
+	packageList            []string
+	packageVersionList     []string
+	packageInstalledList   []string
+	packageInstall         []string
+	packageInstallReport   []string
+	packageUninstall       []string
+	packageUninstallReport []string
+	dataQuery              []string
+
+	targetOrg string
+	pkg       string
+	wait      string
+	noPrompt  string
+	requestID string
+	query     string
+	tooling   string
+}
+
+var sfdxCommands = commandSet{
+	version:                []string{"--version"},
+	orgList:                []string{"force:org:list"},
+	packageList:            []string{"force:package:list"},
+	packageVersionList:     []string{"force:package:version:list"},
+	packageInstalledList:   []string{"force:package:installed:list"},
+	packageInstall:         []string{"force:package:install"},
+	packageInstallReport:   []string{"force:package:install:report"},
+	packageUninstall:       []string{"force:package:uninstall"},
+	packageUninstallReport: []string{"force:package:uninstall:report"},
+	dataQuery:              []string{"force:data:soql:query"},
+
+	targetOrg: "-u",
+	pkg:       "--package",
+	wait:      "-w",
+	noPrompt:  "-r",
+	requestID: "-i",
+	query:     "-q",
+	tooling:   "-t",
+}
+
+var sfCommands = commandSet{
+	version:                []string{"--version"},
+	orgList:                []string{"org", "list"},
+	packageList:            []string{"package", "list"},
+	packageVersionList:     []string{"package", "version", "list"},
+	packageInstalledList:   []string{"package", "installed", "list"},
+	packageInstall:         []string{"package", "install"},
+	packageInstallReport:   []string{"package", "install", "report"},
+	packageUninstall:       []string{"package", "uninstall"},
+	packageUninstallReport: []string{"package", "uninstall", "report"},
+	dataQuery:              []string{"data", "query"},
+
+	targetOrg: "--target-org",
+	pkg:       "--package",
+	wait:      "--wait",
+	noPrompt:  "--no-prompt",
+	requestID: "--request-id",
+	query:     "--query",
+	tooling:   "--use-tooling-api",
+}
+
+// commands returns the commandSet for the CLI targeted by the current Runner.
+func commands() (*commandSet, error) {
+	cli, err := runner.CLI()
+	if err != nil {
+		return nil, err
+	}
+
+	if cli == cliSf {
+		return &sfCommands, nil
+	}
+
+	return &sfdxCommands, nil
+}
+
+func (c *commandSet) versionArgs() []string {
+	return args(c.version)
+}
+
+func (c *commandSet) orgListArgs() []string {
+	return args(c.orgList)
+}
+
+func (c *commandSet) packageListArgs() []string {
+	return args(c.packageList)
+}
+
+func (c *commandSet) packageVersionListArgs() []string {
+	return args(c.packageVersionList)
+}
+
+func (c *commandSet) packageInstalledListArgs(org string) []string {
+	return args(c.packageInstalledList, c.targetOrg, org)
+}
+
+func (c *commandSet) packageInstallArgs(org string, pkg string, wait string) []string {
+	return args(c.packageInstall, c.pkg, pkg, c.targetOrg, org, c.wait, wait, c.noPrompt)
+}
+
+func (c *commandSet) packageInstallReportArgs(org string, requestID string) []string {
+	return args(c.packageInstallReport, c.requestID, requestID, c.targetOrg, org)
+}
+
+func (c *commandSet) packageUninstallArgs(org string, pkg string) []string {
+	return args(c.packageUninstall, c.pkg, pkg, c.targetOrg, org)
+}
+
+func (c *commandSet) packageUninstallReportArgs(org string, requestID string) []string {
+	return args(c.packageUninstallReport, c.requestID, requestID, c.targetOrg, org)
+}
+
+func (c *commandSet) toolingQueryArgs(org string, soql string) []string {
+	return args(c.dataQuery, c.targetOrg, org, c.tooling, c.query, soql)
+}
+
+func args(command []string, flags ...string) []string {
+	a := make([]string, 0, len(command)+len(flags))
+	a = append(a, command...)
+
+	return append(a, flags...)
+}
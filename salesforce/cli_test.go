@@ -0,0 +1,167 @@
+package salesforce
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args func(c *commandSet) []string
+		sf   string
+		sfdx string
+	}{
+		{
+			name: "version",
+			args: func(c *commandSet) []string { return c.versionArgs() },
+			sf:   "--version",
+			sfdx: "--version",
+		},
+		{
+			name: "org list",
+			args: func(c *commandSet) []string { return c.orgListArgs() },
+			sf:   "org list",
+			sfdx: "force:org:list",
+		},
+		{
+			name: "package list",
+			args: func(c *commandSet) []string { return c.packageListArgs() },
+			sf:   "package list",
+			sfdx: "force:package:list",
+		},
+		{
+			name: "package version list",
+			args: func(c *commandSet) []string { return c.packageVersionListArgs() },
+			sf:   "package version list",
+			sfdx: "force:package:version:list",
+		},
+		{
+			name: "installed list",
+			args: func(c *commandSet) []string { return c.packageInstalledListArgs("user1@example.com") },
+			sf:   "package installed list --target-org user1@example.com",
+			sfdx: "force:package:installed:list -u user1@example.com",
+		},
+		{
+			name: "install",
+			args: func(c *commandSet) []string {
+				return c.packageInstallArgs("user1@example.com", "04t000000000001AAA", "0")
+			},
+			sf:   "package install --package 04t000000000001AAA --target-org user1@example.com --wait 0 --no-prompt",
+			sfdx: "force:package:install --package 04t000000000001AAA -u user1@example.com -w 0 -r",
+		},
+		{
+			name: "install report",
+			args: func(c *commandSet) []string {
+				return c.packageInstallReportArgs("user1@example.com", "0Hf000000000001AAA")
+			},
+			sf:   "package install report --request-id 0Hf000000000001AAA --target-org user1@example.com",
+			sfdx: "force:package:install:report -i 0Hf000000000001AAA -u user1@example.com",
+		},
+		{
+			name: "uninstall",
+			args: func(c *commandSet) []string { return c.packageUninstallArgs("user1@example.com", "04t000000000001AAA") },
+			sf:   "package uninstall --package 04t000000000001AAA --target-org user1@example.com",
+			sfdx: "force:package:uninstall --package 04t000000000001AAA -u user1@example.com",
+		},
+		{
+			name: "uninstall report",
+			args: func(c *commandSet) []string {
+				return c.packageUninstallReportArgs("user1@example.com", "06y000000000001AAA")
+			},
+			sf:   "package uninstall report --request-id 06y000000000001AAA --target-org user1@example.com",
+			sfdx: "force:package:uninstall:report -i 06y000000000001AAA -u user1@example.com",
+		},
+		{
+			name: "tooling query",
+			args: func(c *commandSet) []string {
+				return c.toolingQueryArgs("user1@example.com", "SELECT Id FROM Package2")
+			},
+			sf:   "data query --target-org user1@example.com --use-tooling-api --query SELECT Id FROM Package2",
+			sfdx: "force:data:soql:query -u user1@example.com -t -q SELECT Id FROM Package2",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(tt.args(&sfCommands), " "); got != tt.sf {
+			t.Errorf("sf %s args = %q, want %q", tt.name, got, tt.sf)
+		}
+
+		if got := strings.Join(tt.args(&sfdxCommands), " "); got != tt.sfdx {
+			t.Errorf("sfdx %s args = %q, want %q", tt.name, got, tt.sfdx)
+		}
+	}
+}
+
+func TestCommandsFollowRunnerCLI(t *testing.T) {
+	defer SetRunner(&ExecRunner{})
+
+	SetRunner(&stubRunner{})
+	if cmds, err := commands(); err != nil || cmds != &sfCommands {
+		t.Errorf("commands() = %v, %v with sf, want the sf commands", cmds, err)
+	}
+
+	r, err := NewReplayRunner(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.cli = cliSfdx
+	SetRunner(r)
+
+	if cmds, err := commands(); err != nil || cmds != &sfdxCommands {
+		t.Errorf("commands() = %v, %v with sfdx, want the sfdx commands", cmds, err)
+	}
+}
+
+func TestOrgListResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []Org
+	}{
+		{
+			name: "sfdx",
+			json: `{"status":0,"result":{
+				"nonScratchOrgs":[
+					{"username":"hub@example.com","orgId":"00D000000000001AAA","isDevHub":true,"isDefaultDevHubUsername":true,"defaultMarker":"(D)","alias":"hub"},
+					{"username":"uat@example.com","orgId":"00D000000000002AAA","isSandbox":true}
+				],
+				"scratchOrgs":[{"username":"test-1@example.com","orgId":"00D000000000003AAA"}]}}`,
+			want: []Org{
+				{UserName: "hub@example.com", OrgID: "00D000000000001AAA", IsDevHub: true, IsDefaultDevHubUsername: true, DefaultMarker: "(D)", Alias: "hub"},
+				{UserName: "uat@example.com", OrgID: "00D000000000002AAA", IsSandbox: true},
+			},
+		},
+		{
+			name: "sf",
+			json: `{"status":0,"result":{
+				"other":[{"username":"prod@example.com","orgId":"00D000000000004AAA"}],
+				"sandboxes":[{"username":"uat@example.com","orgId":"00D000000000002AAA"}],
+				"nonScratchOrgs":[{"username":"hub@example.com","orgId":"00D000000000001AAA","isDevHub":true,"isDefaultDevHubUsername":true,"alias":"hub"}],
+				"devHubs":[{"username":"hub@example.com","orgId":"00D000000000001AAA","isDevHub":true,"isDefaultDevHubUsername":true,"alias":"hub"}],
+				"scratchOrgs":[{"username":"test-1@example.com","orgId":"00D000000000003AAA"}]}}`,
+			want: []Org{
+				{UserName: "hub@example.com", OrgID: "00D000000000001AAA", IsDevHub: true, IsDefaultDevHubUsername: true, DefaultMarker: defaultMarker, Alias: "hub"},
+				{UserName: "uat@example.com", OrgID: "00D000000000002AAA", IsSandbox: true},
+				{UserName: "prod@example.com", OrgID: "00D000000000004AAA"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var resp orgListResponse
+		if err := json.Unmarshal([]byte(tt.json), &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := resp.nonScratchOrgs(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s nonScratchOrgs() = %+v, want %+v", tt.name, got, tt.want)
+		}
+
+		if len(resp.Result.ScratchOrgs) != 1 || resp.Result.ScratchOrgs[0].UserName != "test-1@example.com" {
+			t.Errorf("%s ScratchOrgs = %+v, want test-1@example.com", tt.name, resp.Result.ScratchOrgs)
+		}
+	}
+}
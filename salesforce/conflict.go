@@ -0,0 +1,288 @@
+package salesforce
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictStrategy decides what happens when the dependency graph
+// requires more than one version of the same package.
+type ConflictStrategy string
+
+// Conflict strategies
+const (
+	// StrategyHighest selects the highest required version when it satisfies every dependent.
+	StrategyHighest ConflictStrategy = "highest"
+	// StrategyFail fails on any conflicting version requirements.
+	StrategyFail ConflictStrategy = "fail"
+)
+
+var conflictStrategy = StrategyHighest
+
+// SetConflictStrategy sets the strategy used to resolve version conflicts during install.
+func SetConflictStrategy(s ConflictStrategy) {
+	conflictStrategy = s
+}
+
+// ParseConflictStrategy validates a conflict strategy name.
+func ParseConflictStrategy(s string) (ConflictStrategy, error) {
+	switch ConflictStrategy(strings.ToLower(s)) {
+	case StrategyHighest:
+		return StrategyHighest, nil
+	case StrategyFail:
+		return StrategyFail, nil
+	default:
+		return "", fmt.Errorf("Unknown conflict strategy %s, expected %s or %s", s, StrategyHighest, StrategyFail)
+	}
+}
+
+// Requirement is a version of a package required by another package,
+// or requested directly when RequiredBy is nil.
+type Requirement struct {
+	RequiredBy *Node
+	Version    *Node
+}
+
+// Conflict lists the different versions of one package required across the graph.
+type Conflict struct {
+	Package      string
+	Requirements []Requirement
+}
+
+// ConflictError reports version conflicts the strategy could not resolve.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	var b strings.Builder
+	for i, c := range e.Conflicts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		fmt.Fprintf(&b, "Version conflict for package %s:", c.Package)
+		for _, r := range c.Requirements {
+			by := "requested directly"
+			if r.RequiredBy != nil {
+				by = r.RequiredBy.String()
+			}
+
+			fmt.Fprintf(&b, "\n  %s requires %s - %s", by, r.Version.Version, r.Version.ID)
+		}
+	}
+
+	return b.String()
+}
+
+// ResolveConflicts finds packages required at more than one version and, following
+// the strategy, replaces them with the single highest version. The highest version is
+// only selected when it satisfies every requirement, otherwise a ConflictError naming
+// each requiring package is returned. Packages are resolved from the roots down, and
+// the dependencies only a replaced version required are pruned before their own
+// versions are compared.
+func (g *Graph) ResolveConflicts(strategy ConflictStrategy) error {
+	groups := make(map[string][]*Node)
+	var pkgIDs []string
+
+	for _, n := range g.resolveOrder() {
+		if n.Pkg == nil {
+			continue
+		}
+
+		if _, ok := groups[n.Pkg.PackageID]; !ok {
+			pkgIDs = append(pkgIDs, n.Pkg.PackageID)
+		}
+		groups[n.Pkg.PackageID] = append(groups[n.Pkg.PackageID], n)
+	}
+
+	var unresolved []Conflict
+	for _, pkgID := range pkgIDs {
+		// Versions only required by a version replaced earlier are gone
+		var versions []*Node
+		for _, n := range groups[pkgID] {
+			if g.nodes[shortID(n.ID)] == n {
+				versions = append(versions, n)
+			}
+		}
+
+		if len(versions) < 2 {
+			continue
+		}
+
+		highest := versions[0]
+		for _, n := range versions[1:] {
+			if highest.Pkg.VersionNumber().Less(n.Pkg.VersionNumber()) {
+				highest = n
+			}
+		}
+
+		compatible := true
+		for _, n := range versions {
+			compatible = compatible && satisfies(highest, n)
+		}
+
+		if strategy == StrategyFail || !compatible {
+			unresolved = append(unresolved, g.conflict(versions))
+			continue
+		}
+
+		for _, n := range versions {
+			if n != highest {
+				fmt.Fprintf(out, "Resolved %s %s to %s\n", n.Name, n.Version, highest.Version)
+				g.replace(n, highest)
+			}
+		}
+
+		g.prune()
+	}
+
+	if len(unresolved) > 0 {
+		return &ConflictError{Conflicts: unresolved}
+	}
+
+	return nil
+}
+
+// satisfies reports whether the package version n can be installed where required was asked for.
+// A dependency names the minimum version its dependent was built against, so any later version
+// satisfies it, except a beta version which can neither upgrade nor be upgraded from another version.
+func satisfies(n *Node, required *Node) bool {
+	if n == required {
+		return true
+	}
+
+	if n.Pkg.IsBeta || required.Pkg.IsBeta {
+		return false
+	}
+
+	return !n.Pkg.VersionNumber().Less(required.Pkg.VersionNumber())
+}
+
+// resolveOrder returns the nodes with every dependent before its dependencies, so a package
+// is resolved before the versions of its own dependencies are compared.
+func (g *Graph) resolveOrder() []*Node {
+	order, err := g.InstallOrder()
+	if err != nil {
+		return g.Nodes()
+	}
+
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+
+	return order
+}
+
+// conflict describes which packages require each of the versions.
+func (g *Graph) conflict(versions []*Node) Conflict {
+	c := Conflict{Package: versions[0].Name}
+
+	for _, n := range versions {
+		for _, root := range g.Roots {
+			if root == n {
+				c.Requirements = append(c.Requirements, Requirement{Version: n})
+			}
+		}
+
+		for _, dependent := range n.Dependents {
+			c.Requirements = append(c.Requirements, Requirement{RequiredBy: dependent, Version: n})
+		}
+	}
+
+	return c
+}
+
+// replace points every dependent of old at n and removes old from the graph.
+func (g *Graph) replace(old *Node, n *Node) {
+	for _, dependent := range old.Dependents {
+		if containsNode(dependent.Dependencies, n) {
+			dependent.Dependencies = removeNode(dependent.Dependencies, old)
+			continue
+		}
+
+		for i, dep := range dependent.Dependencies {
+			if dep == old {
+				dependent.Dependencies[i] = n
+			}
+		}
+		n.Dependents = append(n.Dependents, dependent)
+	}
+
+	for _, dep := range old.Dependencies {
+		dep.Dependents = removeNode(dep.Dependents, old)
+	}
+
+	roots := g.Roots[:0]
+	replaced := false
+	for _, root := range g.Roots {
+		if root == old || root == n {
+			if replaced {
+				continue
+			}
+			root, replaced = n, true
+		}
+
+		roots = append(roots, root)
+	}
+	g.Roots = roots
+
+	delete(g.nodes, shortID(old.ID))
+	g.replaced[shortID(old.ID)] = n
+	g.order = removeNode(g.order, old)
+}
+
+// prune removes the nodes no root depends on anymore, such as the dependencies only a replaced version required.
+func (g *Graph) prune() {
+	reachable := make(map[*Node]bool)
+
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		if reachable[n] {
+			return
+		}
+		reachable[n] = true
+
+		for _, dep := range n.Dependencies {
+			visit(dep)
+		}
+	}
+
+	for _, root := range g.Roots {
+		visit(root)
+	}
+
+	for _, n := range g.Nodes() {
+		if reachable[n] {
+			continue
+		}
+
+		for _, dep := range n.Dependencies {
+			dep.Dependents = removeNode(dep.Dependents, n)
+		}
+
+		delete(g.nodes, shortID(n.ID))
+		g.order = removeNode(g.order, n)
+	}
+}
+
+func removeNode(nodes []*Node, n *Node) []*Node {
+	kept := nodes[:0]
+	for _, node := range nodes {
+		if node != n {
+			kept = append(kept, node)
+		}
+	}
+
+	return kept
+}
+
+func containsNode(nodes []*Node, n *Node) bool {
+	for _, node := range nodes {
+		if node == n {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,137 @@
+package salesforce
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testNode returns a node for the package version id of the package with the given version number.
+func testNode(id string, name string, version string, beta bool) *Node {
+	v, err := ParsePackageVersionNumber(version)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Node{ID: id, Name: name, Version: version, Pkg: &SubscriberPkgVersion{
+		ID:           id,
+		Name:         name,
+		PackageID:    "033" + name,
+		MajorVersion: v.Major,
+		MinorVersion: v.Minor,
+		PatchVersion: v.Patch,
+		BuildNumber:  v.Build,
+		IsBeta:       beta,
+	}}
+}
+
+// nodeIDs lists the IDs of the graph's nodes, sorted.
+func nodeIDs(g *Graph) string {
+	var ids []string
+	for _, n := range g.Nodes() {
+		ids = append(ids, n.ID)
+	}
+	sort.Strings(ids)
+
+	return strings.Join(ids, ",")
+}
+
+func TestResolveConflictsPrunesReplacedDependencies(t *testing.T) {
+	var b bytes.Buffer
+	SetOutput(&b)
+	defer SetOutput(os.Stdout)
+
+	// App needs A 1.0 which needs C 1.0, B needs A 1.1 which needs C 2.0
+	g := NewGraph()
+	app := g.AddRoot(testNode("04tApp", "App", "1.0.0.1", false))
+	pkgB := g.AddNode(testNode("04tB", "B", "1.0.0.1", false))
+	a1 := g.AddNode(testNode("04tA1", "A", "1.0.0.1", false))
+	a2 := g.AddNode(testNode("04tA2", "A", "1.1.0.1", false))
+	c1 := g.AddNode(testNode("04tC1", "C", "1.0.0.1", false))
+	c2 := g.AddNode(testNode("04tC2", "C", "2.0.0.1", false))
+	g.AddEdge(app, a1)
+	g.AddEdge(app, pkgB)
+	g.AddEdge(pkgB, a2)
+	g.AddEdge(a1, c1)
+	g.AddEdge(a2, c2)
+
+	if err := g.ResolveConflicts(StrategyHighest); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := nodeIDs(g), "04tA2,04tApp,04tB,04tC2"; got != want {
+		t.Errorf("nodes = %s, want %s", got, want)
+	}
+
+	if got, want := strings.TrimSpace(b.String()), "Resolved A 1.0.0.1 to 1.1.0.1"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	if len(c2.Dependents) != 1 || c2.Dependents[0] != a2 {
+		t.Errorf("C 2.0 dependents = %v, want only A 1.1", c2.Dependents)
+	}
+
+	order, err := g.InstallOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 4 {
+		t.Errorf("InstallOrder() = %v, want the 4 remaining nodes", order)
+	}
+}
+
+func TestResolveConflicts(t *testing.T) {
+	SetOutput(ioutil.Discard)
+	defer SetOutput(os.Stdout)
+
+	tests := []struct {
+		name     string
+		strategy ConflictStrategy
+		low      *Node
+		high     *Node
+		want     string
+		conflict bool
+	}{
+		{name: "later build", strategy: StrategyHighest, low: testNode("04tX1", "X", "1.2.0.1", false), high: testNode("04tX2", "X", "1.2.0.3", false), want: "04tX2"},
+		{name: "later major version", strategy: StrategyHighest, low: testNode("04tX1", "X", "1.2.0.1", false), high: testNode("04tX2", "X", "2.0.0.1", false), want: "04tX2"},
+		{name: "beta", strategy: StrategyHighest, low: testNode("04tX1", "X", "1.2.0.1", false), high: testNode("04tX2", "X", "1.3.0.1", true), conflict: true},
+		{name: "fail", strategy: StrategyFail, low: testNode("04tX1", "X", "1.2.0.1", false), high: testNode("04tX2", "X", "1.2.0.3", false), conflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGraph()
+			p := g.AddRoot(testNode("04tP", "P", "1.0.0.1", false))
+			q := g.AddRoot(testNode("04tQ", "Q", "1.0.0.1", false))
+			g.AddEdge(p, g.AddNode(tt.low))
+			g.AddEdge(q, g.AddNode(tt.high))
+
+			err := g.ResolveConflicts(tt.strategy)
+
+			var conflictErr *ConflictError
+			if tt.conflict {
+				if !errors.As(err, &conflictErr) || len(conflictErr.Conflicts) != 1 || len(conflictErr.Conflicts[0].Requirements) != 2 {
+					t.Errorf("ResolveConflicts() error = %v, want a conflict between the two requirements", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p.Dependencies[0].ID != tt.want || q.Dependencies[0].ID != tt.want {
+				t.Errorf("dependencies = %s, %s, want both %s", p.Dependencies[0].ID, q.Dependencies[0].ID, tt.want)
+			}
+
+			if g.Node(tt.low.ID) != nil || g.lookup(tt.low.ID) != g.Node(tt.want) {
+				t.Errorf("replaced version %s is still in the graph", tt.low.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package salesforce
+
+import (
+	"fmt"
+	"strings"
+)
+
+const latestVersion = "LATEST"
+
+// Constraint selects package versions by their version number. Supported forms are
+// an exact 1.2.3.4, a wildcard 1.2.x or 1.2.3.LATEST, a caret ^1.2, a tilde ~1.4,
+// space separated comparisons such as >=1.2 <2.0, and LATEST which matches every version.
+type Constraint struct {
+	raw         string
+	comparators []comparator
+}
+
+type comparator struct {
+	op      string
+	version PackageVersionNumber
+}
+
+// ParseConstraint parses a version constraint, an empty constraint matches every version.
+func ParseConstraint(s string) (*Constraint, error) {
+	s = strings.TrimSpace(s)
+	c := &Constraint{raw: s}
+
+	if len(s) == 0 || strings.EqualFold(s, latestVersion) {
+		return c, nil
+	}
+
+	for _, field := range strings.Fields(s) {
+		comparators, err := parseComparator(field)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid version constraint %q: %v", s, err)
+		}
+
+		c.comparators = append(c.comparators, comparators...)
+	}
+
+	return c, nil
+}
+
+// Matches reports whether the version number satisfies every part of the constraint.
+func (c *Constraint) Matches(v PackageVersionNumber) bool {
+	for _, cmp := range c.comparators {
+		if !cmp.matches(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Constraint) String() string {
+	if len(c.raw) == 0 {
+		return latestVersion
+	}
+
+	return c.raw
+}
+
+func (c comparator) matches(v PackageVersionNumber) bool {
+	result := v.Compare(c.version)
+
+	switch c.op {
+	case ">=":
+		return result >= 0
+	case ">":
+		return result > 0
+	case "<=":
+		return result <= 0
+	case "<":
+		return result < 0
+	default:
+		return result == 0
+	}
+}
+
+func parseComparator(s string) ([]comparator, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(s, op) {
+			v, _, err := parseVersionParts(strings.TrimPrefix(s, op))
+			if err != nil {
+				return nil, err
+			}
+
+			return []comparator{{op: op, version: v}}, nil
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(s, "^"):
+		v, n, err := parseRangeVersion(s[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		// Allow changes that do not modify the left-most non-zero part
+		parts := v.parts()
+		i := 0
+		for i < n-1 && parts[i] == 0 {
+			i++
+		}
+
+		return between(v, bump(v, i)), nil
+	case strings.HasPrefix(s, "~"):
+		v, n, err := parseRangeVersion(s[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		// Allow patch and build changes when a minor version is given, otherwise minor changes
+		i := 1
+		if n < 2 {
+			i = 0
+		}
+
+		return between(v, bump(v, i)), nil
+	}
+
+	v, n, err := parseVersionParts(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	if n == 4 {
+		return []comparator{{op: "=", version: v}}, nil
+	}
+
+	// A partial or wildcard version matches every version sharing the given parts
+	return between(v, bump(v, n-1)), nil
+}
+
+// parseRangeVersion parses the version of a caret or tilde range, which needs at least a major version.
+func parseRangeVersion(s string) (PackageVersionNumber, int, error) {
+	v, n, err := parseVersionParts(s)
+	if err == nil && n == 0 {
+		err = fmt.Errorf("missing version number in range %s", s)
+	}
+
+	return v, n, err
+}
+
+// between returns the comparators for lower <= version < upper.
+func between(lower PackageVersionNumber, upper PackageVersionNumber) []comparator {
+	return []comparator{{op: ">=", version: lower}, {op: "<", version: upper}}
+}
+
+// bump increments part i of the version and zeroes the parts after it.
+func bump(v PackageVersionNumber, i int) PackageVersionNumber {
+	p := v.parts()
+	p[i]++
+	for j := i + 1; j < len(p); j++ {
+		p[j] = 0
+	}
+
+	return versionFromParts(p)
+}
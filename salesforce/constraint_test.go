@@ -0,0 +1,89 @@
+package salesforce
+
+import "testing"
+
+func TestConstraintMatches(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		// Exact
+		{constraint: "1.2.3.4", match: []string{"1.2.3.4"}, noMatch: []string{"1.2.3.5", "1.2.3.3"}},
+		{constraint: "1.2.3-4", match: []string{"1.2.3.4"}, noMatch: []string{"1.2.3.5"}},
+		{constraint: "=1.2", match: []string{"1.2.0.0"}, noMatch: []string{"1.2.0.1"}},
+		// Wildcards and partial versions
+		{constraint: "1.2.3.LATEST", match: []string{"1.2.3.0", "1.2.3.9"}, noMatch: []string{"1.2.4.0", "1.2.2.9"}},
+		{constraint: "1.2.3.latest", match: []string{"1.2.3.7"}, noMatch: []string{"1.3.0.0"}},
+		{constraint: "1.2.x", match: []string{"1.2.0.0", "1.2.9.9"}, noMatch: []string{"1.3.0.0", "1.1.9.9"}},
+		{constraint: "1.*", match: []string{"1.0.0.1", "1.9.0.0"}, noMatch: []string{"2.0.0.0"}},
+		{constraint: "1.2", match: []string{"1.2.0.0", "1.2.5.1"}, noMatch: []string{"1.3.0.0"}},
+		// Ranges
+		{constraint: ">=1.2 <2.0", match: []string{"1.2.0.0", "1.9.9.9"}, noMatch: []string{"1.1.9.9", "2.0.0.0"}},
+		{constraint: ">1.2.0.0 <=1.4.0.0", match: []string{"1.2.0.1", "1.4.0.0"}, noMatch: []string{"1.2.0.0", "1.4.0.1"}},
+		// Caret
+		{constraint: "^1.2", match: []string{"1.2.0.0", "1.9.0.0"}, noMatch: []string{"1.1.0.0", "2.0.0.0"}},
+		{constraint: "^0.3.1", match: []string{"0.3.1.0", "0.3.9.0"}, noMatch: []string{"0.4.0.0", "0.3.0.0"}},
+		{constraint: "^0.0.4.2", match: []string{"0.0.4.2", "0.0.4.9"}, noMatch: []string{"0.0.5.0"}},
+		// Tilde
+		{constraint: "~1.4", match: []string{"1.4.0.0", "1.4.9.9"}, noMatch: []string{"1.5.0.0", "1.3.9.9"}},
+		{constraint: "~1.4.2", match: []string{"1.4.2.0", "1.4.9.0"}, noMatch: []string{"1.5.0.0", "1.4.1.0"}},
+		{constraint: "~2", match: []string{"2.0.0.0", "2.9.9.9"}, noMatch: []string{"3.0.0.0"}},
+		// Every version
+		{constraint: "", match: []string{"0.0.0.0", "9.9.9.9"}},
+		{constraint: "LATEST", match: []string{"0.0.0.1", "9.9.9.9"}},
+		{constraint: "x", match: []string{"4.0.0.0"}},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q) error = %v", tt.constraint, err)
+			continue
+		}
+
+		for _, s := range tt.match {
+			if !c.Matches(mustParseVersion(t, s)) {
+				t.Errorf("%q does not match %s", tt.constraint, s)
+			}
+		}
+
+		for _, s := range tt.noMatch {
+			if c.Matches(mustParseVersion(t, s)) {
+				t.Errorf("%q matches %s", tt.constraint, s)
+			}
+		}
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, s := range []string{"1.2.3.4.5", "abc", "1.two", ">=", "^", "~x.1", "1.-2", ">=1.0 <two"} {
+		if c, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) = %v, want an error", s, c)
+		}
+	}
+}
+
+func TestConstraintString(t *testing.T) {
+	for s, want := range map[string]string{"": "LATEST", " ^1.2 ": "^1.2", ">=1.0 <2.0": ">=1.0 <2.0"} {
+		c, err := ParseConstraint(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.String() != want {
+			t.Errorf("ParseConstraint(%q).String() = %q, want %q", s, c.String(), want)
+		}
+	}
+}
+
+func mustParseVersion(t *testing.T, s string) PackageVersionNumber {
+	t.Helper()
+
+	v, err := ParsePackageVersionNumber(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
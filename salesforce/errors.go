@@ -0,0 +1,166 @@
+package salesforce
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Well known failures reported by the Salesforce CLI. Use errors.Is to test for them.
+var (
+	ErrAuthExpired        = errors.New("Org authorization has expired")
+	ErrNoDefaultDevHub    = errors.New("No default dev hub org found")
+	ErrPackageNotFound    = errors.New("Package not found")
+	ErrInstallKeyRequired = errors.New("Package installation key required")
+	ErrUpgradeNotAllowed  = errors.New("Package upgrade not allowed")
+
+	// Transient failures which may succeed when retried
+	ErrVersionUnavailable = errors.New("Package version not yet available")
+	ErrRowLock            = errors.New("Unable to lock row")
+	ErrConcurrentInstall  = errors.New("Another package install is in progress")
+)
+
+// errorClass matches cli error names and Salesforce error codes to a sentinel error.
+type errorClass struct {
+	err   error
+	names []string
+}
+
+var errorClasses = []errorClass{
+	{err: ErrAuthExpired, names: []string{"RefreshTokenAuthError", "AuthInfoCreationError", "INVALID_SESSION_ID", "invalid_grant"}},
+	{err: ErrNoDefaultDevHub, names: []string{"NoDefaultDevHubError", "NoDevHubError", "NoDefaultEnvError"}},
+	{err: ErrPackageNotFound, names: []string{"PackageNotFound", "ErrorPackageNotFound", "PackageVersionNotFound", "PackageAliasNotFoundError", "INVALID_PACKAGE_VERSION"}},
+	{err: ErrInstallKeyRequired, names: []string{"InstallationKeyRequired", "INSTALL_KEY_REQUIRED", "INSTALL_KEY_INVALID"}},
+	{err: ErrUpgradeNotAllowed, names: []string{"UpgradeNotAllowed", "UPGRADE_NOT_ALLOWED"}},
+	{err: ErrVersionUnavailable, names: []string{"PackageVersionNotAvailable", "PACKAGE_UNAVAILABLE"}},
+	{err: ErrRowLock, names: []string{"UNABLE_TO_LOCK_ROW"}},
+	{err: ErrConcurrentInstall, names: []string{"ALREADY_IN_PROGRESS", "PackageInstallInProgress"}},
+}
+
+// errorCode matches the Salesforce error code prefixing a message, as in "UNABLE_TO_LOCK_ROW: unable to obtain exclusive access".
+var errorCode = regexp.MustCompile(`^\(?([A-Z][A-Z0-9]*(?:_[A-Z0-9]+)+)\)?:`)
+
+// classify returns the sentinel error matching the error name, or the error code prefixing the message, or nil.
+func classify(name string, message string) error {
+	if err := classifyName(name); err != nil {
+		return err
+	}
+
+	if m := errorCode.FindStringSubmatch(strings.TrimSpace(message)); m != nil {
+		return classifyName(m[1])
+	}
+
+	return nil
+}
+
+func classifyName(name string) error {
+	if len(name) == 0 {
+		return nil
+	}
+
+	for _, class := range errorClasses {
+		for _, n := range class.names {
+			if strings.EqualFold(n, name) {
+				return class.err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SfdxError represents the JSON error envelope returned by the cli when a command fails.
+type SfdxError struct {
+	Status   int
+	Name     string
+	Message  string
+	ExitCode int
+	Actions  []string
+}
+
+func (e *SfdxError) Error() string {
+	if len(e.Message) > 0 {
+		return e.Message
+	}
+
+	return e.Name
+}
+
+// Is reports whether the error is classified as the target sentinel error.
+func (e *SfdxError) Is(target error) bool {
+	return classify(e.Name, e.Message) == target
+}
+
+// Is reports whether the error is classified as the target sentinel error.
+func (e *APIError) Is(target error) bool {
+	return classify(e.ErrorCode, e.Message) == target
+}
+
+// InstallRequestError represents the errors reported by a failed PackageInstallRequest.
+type InstallRequestError struct {
+	RequestID string
+	Messages  []string
+}
+
+func (e *InstallRequestError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("Install request %s failed", e.RequestID)
+	}
+
+	return fmt.Sprintf("Install request %s failed:\n  %s", e.RequestID, strings.Join(e.Messages, "\n  "))
+}
+
+// Is reports whether any of the install request's errors is classified as the target sentinel error.
+func (e *InstallRequestError) Is(target error) bool {
+	for _, message := range e.Messages {
+		if classify("", message) == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeSfdxError returns the SfdxError described by the cli's JSON
+// output, or nil when the output does not describe a failure.
+func decodeSfdxError(out []byte) *SfdxError {
+	start := bytes.IndexByte(out, '{')
+	if start < 0 {
+		return nil
+	}
+
+	var sfErr SfdxError
+	if err := json.Unmarshal(out[start:], &sfErr); err != nil {
+		return nil
+	}
+
+	if sfErr.Status == 0 {
+		return nil
+	}
+
+	return &sfErr
+}
+
+// sfErrorLine and sfdxErrorLine match the error printed by sf, "Error (Name): message",
+// and by sfdx, "ERROR running command:  message", when a command runs without --json.
+var (
+	sfErrorLine   = regexp.MustCompile(`(?m)^Error \(([^)]+)\): (.+)$`)
+	sfdxErrorLine = regexp.MustCompile(`(?m)^ERROR running \S+:\s+(.+)$`)
+)
+
+// decodeSfdxText returns the SfdxError described by the cli's error output
+// when run without --json, or nil when the output does not describe a failure.
+func decodeSfdxText(out string) *SfdxError {
+	if m := sfErrorLine.FindStringSubmatch(out); m != nil {
+		return &SfdxError{Status: 1, Name: m[1], Message: strings.TrimSpace(m[2])}
+	}
+
+	if m := sfdxErrorLine.FindStringSubmatch(out); m != nil {
+		return &SfdxError{Status: 1, Message: strings.TrimSpace(m[1])}
+	}
+
+	return nil
+}
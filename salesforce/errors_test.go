@@ -0,0 +1,107 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    error
+	}{
+		{name: "NoDefaultDevHubError", message: "No default dev hub found.", want: ErrNoDefaultDevHub},
+		{name: "INVALID_SESSION_ID", message: "Session expired or invalid", want: ErrAuthExpired},
+		{name: "upgrade_not_allowed", message: "", want: ErrUpgradeNotAllowed},
+		{message: "UNABLE_TO_LOCK_ROW: unable to obtain exclusive access to this record", want: ErrRowLock},
+		{message: "(ALREADY_IN_PROGRESS): Another package is being installed", want: ErrConcurrentInstall},
+		// Messages only mentioning a failure are not classified
+		{message: "Enter the installation key for the package", want: nil},
+		{message: "The deploy is already in progress elsewhere", want: nil},
+		{name: "GenericError", message: "Package not found", want: nil},
+		{name: "", message: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := classify(tt.name, tt.message); got != tt.want {
+			t.Errorf("classify(%q, %q) = %v, want %v", tt.name, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeSfdxError(t *testing.T) {
+	out := []byte(`Warning: update available
+{"status":1,"name":"NoDefaultDevHubError","message":"No default dev hub found.","exitCode":1}`)
+
+	sfErr := decodeSfdxError(out)
+	if sfErr == nil || sfErr.Name != "NoDefaultDevHubError" || !errors.Is(sfErr, ErrNoDefaultDevHub) {
+		t.Errorf("decodeSfdxError() = %+v, want NoDefaultDevHubError", sfErr)
+	}
+
+	if sfErr := decodeSfdxError([]byte(`{"status":0,"result":[]}`)); sfErr != nil {
+		t.Errorf("decodeSfdxError() = %+v for a successful result, want nil", sfErr)
+	}
+}
+
+func TestDecodeSfdxText(t *testing.T) {
+	tests := []struct {
+		out     string
+		name    string
+		message string
+	}{
+		{out: "exit status 1\nError (NoDefaultDevHubError): No default dev hub found.\n", name: "NoDefaultDevHubError", message: "No default dev hub found."},
+		{out: "exit status 1\nERROR running force:package:install:  INSTALL_KEY_REQUIRED: Installation key required", message: "INSTALL_KEY_REQUIRED: Installation key required"},
+	}
+
+	for _, tt := range tests {
+		sfErr := decodeSfdxText(tt.out)
+		if sfErr == nil || sfErr.Name != tt.name || sfErr.Message != tt.message {
+			t.Errorf("decodeSfdxText(%q) = %+v, want %s: %s", tt.out, sfErr, tt.name, tt.message)
+		}
+	}
+
+	if sfErr := decodeSfdxText("exit status 1"); sfErr != nil {
+		t.Errorf("decodeSfdxText() = %+v without an error line, want nil", sfErr)
+	}
+
+	if !errors.Is(decodeSfdxText("ERROR running force:package:install:  INSTALL_KEY_REQUIRED: key required"), ErrInstallKeyRequired) {
+		t.Errorf("decodeSfdxText() is not classified by the error code of its message")
+	}
+}
+
+// failingRunner fails every command with the output and exit error of a cli that crashed.
+type failingRunner struct {
+	out string
+}
+
+func (r *failingRunner) CLI() (string, error) {
+	return cliSf, nil
+}
+
+func (r *failingRunner) Run(ctx context.Context, arg ...string) error {
+	return errors.New("exit status 1")
+}
+
+func (r *failingRunner) RunJSON(ctx context.Context, arg ...string) ([]byte, error) {
+	return []byte(r.out), errors.New("exit status 1")
+}
+
+func TestSfdxJTextErrors(t *testing.T) {
+	defer SetRunner(&ExecRunner{})
+
+	// Errors printed as text are decoded
+	SetRunner(&failingRunner{out: "Error (NoDefaultDevHubError): No default dev hub found.\n"})
+	if _, err := sfdxJ(context.Background(), "org", "list"); !errors.Is(err, ErrNoDefaultDevHub) {
+		t.Errorf("sfdxJ() error = %v, want ErrNoDefaultDevHub", err)
+	}
+
+	// Other output is kept with the exit error
+	SetRunner(&failingRunner{out: "TypeError: Cannot read properties of undefined (reading 'username')\n    at Object.run\n"})
+	_, err := sfdxJ(context.Background(), "org", "list")
+	if err == nil || !strings.HasPrefix(err.Error(), "exit status 1\nTypeError: Cannot read properties") {
+		t.Errorf("sfdxJ() error = %v, want the exit error and the cli output", err)
+	}
+}
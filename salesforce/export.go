@@ -0,0 +1,202 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// InstallState is how a node's package version compares to the version installed in an org.
+type InstallState string
+
+// Install states
+const (
+	// StateInstalled means the version, or a later one, is installed.
+	StateInstalled InstallState = "installed"
+	// StateOutdated means an earlier version of the package is installed.
+	StateOutdated InstallState = "outdated"
+	// StateMissing means no version of the package is installed.
+	StateMissing InstallState = "missing"
+)
+
+// Graph export formats
+const (
+	FormatDot     = "dot"
+	FormatMermaid = "mermaid"
+	FormatJSON    = "json"
+)
+
+// stateColors are the fill colours of each install state in exported graphs.
+var stateColors = map[InstallState]string{
+	StateInstalled: "#b7e1a1",
+	StateOutdated:  "#f7d08a",
+	StateMissing:   "#f4a6a6",
+}
+
+// InstallStates compares every node of the graph resolved to a package version to the packages installed in the org.
+func InstallStates(ctx context.Context, g *Graph, org string) (map[*Node]InstallState, error) {
+	org, err := getOrgUserID(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[*Node]InstallState)
+	for _, n := range g.Nodes() {
+		// Nodes of a project graph are not resolved to a package version
+		if n.Pkg == nil {
+			continue
+		}
+
+		current, err := installedPackage(ctx, org, n.Pkg.PackageID)
+		if err != nil {
+			return nil, err
+		}
+
+		states[n] = StateMissing
+		if current == nil {
+			continue
+		}
+
+		states[n] = StateInstalled
+		if num, err := current.VersionNumber(); err == nil && num.Less(n.Pkg.VersionNumber()) {
+			states[n] = StateOutdated
+		}
+	}
+
+	return states, nil
+}
+
+// ParseGraphFormat validates a graph export format name.
+func ParseGraphFormat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case FormatDot:
+		return FormatDot, nil
+	case FormatMermaid:
+		return FormatMermaid, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("Unknown graph format %s, expected %s, %s or %s", s, FormatDot, FormatMermaid, FormatJSON)
+	}
+}
+
+// ExportGraph writes the graph to w as a Graphviz dot digraph, a Mermaid flowchart or JSON.
+// Nodes are coloured by their install state when states is not nil.
+func ExportGraph(w io.Writer, g *Graph, format string, states map[*Node]InstallState) error {
+	format, err := ParseGraphFormat(format)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatMermaid:
+		return exportMermaid(w, g, states)
+	case FormatJSON:
+		return exportJSON(w, g, states)
+	default:
+		return exportDot(w, g, states)
+	}
+}
+
+func exportDot(w io.Writer, g *Graph, states map[*Node]InstallState) error {
+	var b strings.Builder
+
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, n := range g.Nodes() {
+		attrs := fmt.Sprintf("label=%q", n.Name+"\n"+n.Version)
+		if state, ok := states[n]; ok {
+			attrs += fmt.Sprintf(", style=filled, fillcolor=%q, tooltip=%q", stateColors[state], state)
+		}
+
+		fmt.Fprintf(&b, "  %q [%s];\n", n.ID, attrs)
+	}
+
+	for _, n := range g.Nodes() {
+		for _, dep := range n.Dependencies {
+			fmt.Fprintf(&b, "  %q -> %q;\n", n.ID, dep.ID)
+		}
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func exportMermaid(w io.Writer, g *Graph, states map[*Node]InstallState) error {
+	var b strings.Builder
+
+	b.WriteString("graph TD\n")
+
+	for _, n := range g.Nodes() {
+		label := strings.ReplaceAll(n.String(), `"`, "#quot;")
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", mermaidID(n), label)
+	}
+
+	for _, n := range g.Nodes() {
+		for _, dep := range n.Dependencies {
+			fmt.Fprintf(&b, "  %s --> %s\n", mermaidID(n), mermaidID(dep))
+		}
+	}
+
+	if states != nil {
+		for _, state := range []InstallState{StateInstalled, StateOutdated, StateMissing} {
+			fmt.Fprintf(&b, "  classDef %s fill:%s\n", state, stateColors[state])
+		}
+
+		for _, n := range g.Nodes() {
+			if state, ok := states[n]; ok {
+				fmt.Fprintf(&b, "  class %s %s\n", mermaidID(n), state)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidID prefixes the node's ID so it never starts with a digit.
+func mermaidID(n *Node) string {
+	return "pkg_" + n.ID
+}
+
+type graphJSON struct {
+	Nodes []nodeJSON `json:"nodes"`
+	Edges []edgeJSON `json:"edges"`
+}
+
+type nodeJSON struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	Version string       `json:"version"`
+	State   InstallState `json:"state,omitempty"`
+}
+
+type edgeJSON struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func exportJSON(w io.Writer, g *Graph, states map[*Node]InstallState) error {
+	export := graphJSON{Nodes: []nodeJSON{}, Edges: []edgeJSON{}}
+
+	for _, n := range g.Nodes() {
+		export.Nodes = append(export.Nodes, nodeJSON{ID: n.ID, Name: n.Name, Version: n.Version, State: states[n]})
+
+		for _, dep := range n.Dependencies {
+			export.Edges = append(export.Edges, edgeJSON{From: n.ID, To: dep.ID})
+		}
+	}
+
+	bytes, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(bytes, '\n'))
+	return err
+}
@@ -0,0 +1,78 @@
+package salesforce
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallStatesUnresolvedNodes(t *testing.T) {
+	useTestProject(t, `{"packageDirectories": [
+		{"path": "app", "package": "App", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "Base"}]}
+	], "packageAliases": {"Base": "04t000000000001AAA"}}`)
+
+	g, err := ProjectGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	states, err := InstallStates(ctx, g, "user1@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(states) != 0 {
+		t.Errorf("InstallStates() = %v, want no states for unresolved nodes", states)
+	}
+
+	installed, err := isNodeInstalled(ctx, "user1@example.com", g.Roots[0])
+	if err != nil || installed {
+		t.Errorf("isNodeInstalled() = %t, %v, want false for an unresolved node", installed, err)
+	}
+}
+
+func TestExportGraphGolden(t *testing.T) {
+	g := NewGraph()
+	app := g.AddRoot(testNode("04t000000000002AAA", "App", "2.0.0.1", false))
+	base := g.AddNode(testNode("04t000000000001AAA", "Base", "1.2.0.1", false))
+	g.AddEdge(app, base)
+
+	states := map[*Node]InstallState{app: StateMissing, base: StateOutdated}
+
+	for _, format := range []string{FormatDot, FormatMermaid, FormatJSON} {
+		for _, withStates := range []bool{false, true} {
+			name := format
+			var nodeStates map[*Node]InstallState
+			if withStates {
+				name += "-states"
+				nodeStates = states
+			}
+
+			t.Run(name, func(t *testing.T) {
+				var b bytes.Buffer
+				if err := ExportGraph(&b, g, format, nodeStates); err != nil {
+					t.Fatal(err)
+				}
+
+				golden := filepath.Join("testdata", "export", name+".golden")
+				if *updateGolden {
+					if err := ioutil.WriteFile(golden, b.Bytes(), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				want, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(b.Bytes(), want) {
+					t.Errorf("ExportGraph(%s) =\n%s\nwant\n%s", format, b.Bytes(), want)
+				}
+			})
+		}
+	}
+}
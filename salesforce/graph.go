@@ -0,0 +1,231 @@
+package salesforce
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Node is a package version in a dependency Graph.
+type Node struct {
+	// ID is the subscriber package version (04t) ID.
+	ID      string
+	Name    string
+	Version string
+	// Pkg is the SubscriberPackageVersion the node was built from, if any.
+	Pkg *SubscriberPkgVersion
+
+	Dependencies []*Node
+	Dependents   []*Node
+}
+
+// String returns the node's name and version.
+func (n *Node) String() string {
+	if len(n.Version) == 0 {
+		return n.Name
+	}
+
+	return fmt.Sprintf("%s %s", n.Name, n.Version)
+}
+
+// Graph is a dependency graph of package versions keyed by their 04t ID, in its 15 or 18 character form.
+// Shared dependencies are represented by a single node.
+type Graph struct {
+	// Roots are the packages the graph was resolved for.
+	Roots []*Node
+
+	nodes map[string]*Node
+	order []*Node
+	// replaced maps the IDs of nodes removed by conflict resolution to the nodes replacing them.
+	replaced map[string]*Node
+}
+
+// NewGraph returns an empty Graph.
+func NewGraph() *Graph {
+	return &Graph{nodes: make(map[string]*Node), replaced: make(map[string]*Node)}
+}
+
+// Node returns the node with the given ID, or nil.
+func (g *Graph) Node(id string) *Node {
+	return g.nodes[shortID(id)]
+}
+
+// lookup returns the node with the given ID, or the node that replaced it when resolving conflicts.
+func (g *Graph) lookup(id string) *Node {
+	if n, ok := g.nodes[shortID(id)]; ok {
+		return n
+	}
+
+	if n, ok := g.replaced[shortID(id)]; ok {
+		return g.lookup(n.ID)
+	}
+
+	return nil
+}
+
+// Nodes returns every node in the order they were added.
+func (g *Graph) Nodes() []*Node {
+	return append([]*Node(nil), g.order...)
+}
+
+// AddNode adds n to the graph, returning the existing node when one with the same ID was already added.
+func (g *Graph) AddNode(n *Node) *Node {
+	if existing, ok := g.nodes[shortID(n.ID)]; ok {
+		return existing
+	}
+
+	g.nodes[shortID(n.ID)] = n
+	g.order = append(g.order, n)
+
+	return n
+}
+
+// AddRoot adds n to the graph as one of its roots.
+func (g *Graph) AddRoot(n *Node) *Node {
+	n = g.AddNode(n)
+
+	for _, root := range g.Roots {
+		if root == n {
+			return n
+		}
+	}
+
+	g.Roots = append(g.Roots, n)
+	return n
+}
+
+// AddEdge records that from depends on to.
+func (g *Graph) AddEdge(from *Node, to *Node) {
+	for _, dep := range from.Dependencies {
+		if dep == to {
+			return
+		}
+	}
+
+	from.Dependencies = append(from.Dependencies, to)
+	to.Dependents = append(to.Dependents, from)
+}
+
+// InstallOrder returns every node reachable from the roots, ordered so each node
+// comes after all of its dependencies. A CycleError is returned when the
+// dependencies form a cycle and no such order exists.
+func (g *Graph) InstallOrder() ([]*Node, error) {
+	var order []*Node
+	done := make(map[*Node]bool)
+	var path []*Node
+
+	var visit func(n *Node) error
+	visit = func(n *Node) error {
+		if done[n] {
+			return nil
+		}
+
+		for i, p := range path {
+			if p == n {
+				cycle := append(append([]*Node(nil), path[i:]...), n)
+				return &CycleError{Path: cycle}
+			}
+		}
+
+		path = append(path, n)
+		for _, dep := range n.Dependencies {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+
+		done[n] = true
+		order = append(order, n)
+		return nil
+	}
+
+	for _, root := range g.Roots {
+		if err := visit(root); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// CycleError reports a dependency cycle. Path starts and ends with the same node.
+type CycleError struct {
+	Path []*Node
+}
+
+func (e *CycleError) Error() string {
+	names := make([]string, 0, len(e.Path))
+	for _, n := range e.Path {
+		names = append(names, n.String())
+	}
+
+	return "Dependency cycle detected: " + strings.Join(names, " -> ")
+}
+
+// ResolveGraph builds the dependency graph of the specified packages, by name or ID,
+// from the SubscriberPackageVersion records visible to the specified org.
+func ResolveGraph(ctx context.Context, org string, pkgs []string) (*Graph, error) {
+	if err := CheckCli(); err != nil {
+		return nil, err
+	}
+
+	org, err := getOrgUserID(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	beta, err := allowBeta(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		id, err := resolvePkgVersionID(ctx, org, pkg, beta)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return buildGraph(ctx, org, ids)
+}
+
+// buildGraph builds the dependency graph of the specified 04t IDs.
+func buildGraph(ctx context.Context, org string, ids []string) (*Graph, error) {
+	resolved, err := resolveDependencies(ctx, org, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewGraph()
+
+	var add func(id string) *Node
+	add = func(id string) *Node {
+		if n := g.Node(id); n != nil {
+			return n
+		}
+
+		pkv := resolved[id]
+		n := g.AddNode(&Node{
+			ID:      pkv.ID,
+			Name:    pkv.Name,
+			Version: pkv.VersionNumber().String(),
+			Pkg:     pkv,
+		})
+
+		for _, dep := range pkv.Dependencies.Ids {
+			g.AddEdge(n, add(dep.SubscriberPackageVersionID))
+		}
+
+		return n
+	}
+
+	for _, id := range ids {
+		g.AddRoot(add(id))
+	}
+
+	return g, nil
+}
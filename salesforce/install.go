@@ -0,0 +1,197 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	installRequestPrefix = "0Hf"
+
+	installStatusSuccess = "SUCCESS"
+	installStatusError   = "ERROR"
+)
+
+var pollInterval = 10 * time.Second
+
+// SetPollInterval sets how often the status of a package install request is checked.
+func SetPollInterval(d time.Duration) {
+	if d > 0 {
+		pollInterval = d
+	}
+}
+
+// PackageInstallRequest represents a PackageInstallRequest (0Hf) object tracking an asynchronous package install
+type PackageInstallRequest struct {
+	ID                          string `json:"Id"`
+	Status                      string
+	SubscriberPackageVersionKey string
+	Errors                      struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+}
+
+// Done reports whether the install request has finished, successfully or not.
+func (r *PackageInstallRequest) Done() bool {
+	return r.Status == installStatusSuccess || r.Status == installStatusError
+}
+
+// Err returns the errors reported by a failed install request, or nil.
+func (r *PackageInstallRequest) Err() error {
+	if r.Status != installStatusError {
+		return nil
+	}
+
+	messages := make([]string, 0, len(r.Errors.Errors))
+	for _, e := range r.Errors.Errors {
+		messages = append(messages, e.Message)
+	}
+
+	return &InstallRequestError{RequestID: r.ID, Messages: messages}
+}
+
+type installRequestResponse struct {
+	Status int
+	Result PackageInstallRequest
+}
+
+// InstallStatus returns the current state of the install request in the specified org.
+func InstallStatus(ctx context.Context, org string, requestID string) (*PackageInstallRequest, error) {
+	if err := CheckCli(); err != nil {
+		return nil, err
+	}
+
+	org, err := getOrgUserID(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	return installReport(ctx, org, requestID)
+}
+
+// WaitForInstall polls the install request in the specified org, printing each change
+// of status, until it succeeds, fails or the install timeout passes.
+func WaitForInstall(ctx context.Context, org string, requestID string) (*PackageInstallRequest, error) {
+	if err := CheckCli(); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(requestID, installRequestPrefix) {
+		return nil, fmt.Errorf("%s is not a package install request ID", requestID)
+	}
+
+	org, err := getOrgUserID(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	return waitForInstall(ctx, org, requestID)
+}
+
+// submitInstall starts the package install without waiting for it to finish.
+func submitInstall(ctx context.Context, org string, pkg string) (*PackageInstallRequest, error) {
+	cmds, err := commands()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBytes, err := sfdxJ(ctx, cmds.packageInstallArgs(org, pkg, "0")...)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp installRequestResponse
+	err = json.Unmarshal(jsonBytes, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Result.ID) == 0 {
+		return nil, fmt.Errorf("No install request was returned for package: %s", pkg)
+	}
+
+	return &resp.Result, nil
+}
+
+func installReport(ctx context.Context, org string, requestID string) (*PackageInstallRequest, error) {
+	cmds, err := commands()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBytes, err := sfdxJ(ctx, cmds.packageInstallReportArgs(org, requestID)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp installRequestResponse
+	err = json.Unmarshal(jsonBytes, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Result, nil
+}
+
+func waitForInstall(ctx context.Context, org string, requestID string) (*PackageInstallRequest, error) {
+	waitCtx, cancel := withTimeout(ctx, timeouts.Install)
+	defer cancel()
+
+	start := time.Now()
+	status := ""
+
+	for {
+		req, err := installReport(waitCtx, org, requestID)
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return nil, stillRunning(org, requestID)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if req.Status != status {
+			status = req.Status
+			fmt.Fprintf(out, "Install request %s: %s (%s)\n", requestID, installStatusLabel(status), time.Since(start).Round(time.Second))
+		}
+
+		if req.Done() {
+			return req, req.Err()
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() == nil {
+				return nil, stillRunning(org, requestID)
+			}
+
+			return nil, fmt.Errorf("Stopped waiting for install request %s: %w", requestID, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func stillRunning(org string, requestID string) error {
+	return fmt.Errorf("Install request %s is still running after %s, check on it with: dxpm status %s -o %s", requestID, timeouts.Install, requestID, org)
+}
+
+func installStatusLabel(status string) string {
+	switch status {
+	case "", "QUEUED":
+		return "Queued"
+	case "IN_PROGRESS":
+		return "In Progress"
+	case installStatusSuccess:
+		return "Success"
+	case installStatusError:
+		return "Error"
+	default:
+		return status
+	}
+}
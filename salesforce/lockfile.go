@@ -0,0 +1,420 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	lockFileName    = "dxpm-lock.json"
+	lockfileVersion = 1
+)
+
+// ErrLockfileOutdated is returned by frozen lockfile installs when the lockfile is missing,
+// does not lock every package being installed or installing would change it.
+var ErrLockfileOutdated = errors.New("Lockfile is missing or out of date")
+
+// LockfileMode controls how installs use the project's lockfile.
+type LockfileMode int
+
+// Lockfile modes
+const (
+	// LockfileUse installs the locked versions, resolving and locking packages not yet in the lockfile.
+	LockfileUse LockfileMode = iota
+	// LockfileUpdate resolves every package again and rewrites the lockfile.
+	LockfileUpdate
+	// LockfileFrozen only installs locked versions, never writes the lockfile and fails when installing would change it.
+	LockfileFrozen
+)
+
+var lockfileMode = LockfileUse
+
+// SetLockfileMode sets how installs use the project's lockfile.
+func SetLockfileMode(m LockfileMode) {
+	lockfileMode = m
+}
+
+// Lockfile records the package versions each package reference resolved to, so
+// later installs of the same references install the same versions.
+type Lockfile struct {
+	Version int `json:"lockfileVersion"`
+	// Requests maps each installed package reference to its resolved 04t ID.
+	Requests map[string]string `json:"requests"`
+	// Packages maps the 04t ID of every resolved package version to its details.
+	Packages map[string]*LockedPackage `json:"packages"`
+}
+
+// LockedPackage is a resolved package version and the 04t IDs of its resolved dependencies.
+type LockedPackage struct {
+	Name string `json:"name"`
+	// PackageID is the 0Ho package ID, only known for packages owned by the dev hub.
+	PackageID           string   `json:"packageId,omitempty"`
+	SubscriberPackageID string   `json:"subscriberPackageId"`
+	Version             string   `json:"version"`
+	PackageType         string   `json:"packageType,omitempty"`
+	Beta                bool     `json:"beta,omitempty"`
+	Dependencies        []string `json:"dependencies,omitempty"`
+}
+
+func newLockfile() *Lockfile {
+	return &Lockfile{
+		Version:  lockfileVersion,
+		Requests: make(map[string]string),
+		Packages: make(map[string]*LockedPackage),
+	}
+}
+
+// lockfilePath returns the path of the lockfile next to the project file.
+func lockfilePath() string {
+	return filepath.Join(filepath.Dir(projectPath), lockFileName)
+}
+
+// readLockfile reads the project's lockfile, returning nil when there is none.
+func readLockfile() (*Lockfile, error) {
+	data, err := ioutil.ReadFile(lockfilePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	lock := newLockfile()
+	err = json.Unmarshal(data, lock)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s: %v", lockFileName, err)
+	}
+
+	return lock, nil
+}
+
+func (l *Lockfile) write() error {
+	bytes, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(lockfilePath(), append(bytes, '\n'), 0644)
+}
+
+// lockedGraph resolves the dependency graph of the package references, using the versions
+// locked in the project's lockfile. When save is set, newly resolved versions are locked
+// as the lockfile mode allows.
+func lockedGraph(ctx context.Context, org string, refs []string, save bool) (*Graph, error) {
+	lock, err := readLockfile()
+	if err != nil {
+		return nil, err
+	}
+
+	if lockfileMode == LockfileFrozen {
+		if lock == nil {
+			return nil, fmt.Errorf("%w: %s not found", ErrLockfileOutdated, lockFileName)
+		}
+
+		ids := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			id, ok := lock.Requests[ref]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s is not locked", ErrLockfileOutdated, ref)
+			}
+
+			ids = append(ids, id)
+		}
+
+		return frozenGraph(ctx, org, lock, ids)
+	}
+
+	if lock == nil {
+		lock = newLockfile()
+	}
+
+	beta, err := allowBeta(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(refs))
+	changed := false
+	for _, ref := range refs {
+		id, ok := lock.Requests[ref]
+		if !ok || lockfileMode == LockfileUpdate {
+			id, err = resolvePkgVersionID(ctx, org, ref, beta)
+			if err != nil {
+				return nil, err
+			}
+
+			if !sameID(id, lock.Requests[ref]) {
+				changed = true
+				lock.Requests[ref] = id
+			}
+		}
+
+		ids = append(ids, id)
+	}
+
+	if !changed {
+		// Resolve again when the lockfile is missing any of the locked dependencies
+		if g, err := lock.graph(ids); err == nil {
+			fmt.Fprintf(out, "Using locked versions from %s\n", lockFileName)
+			return g, nil
+		}
+	}
+
+	g, err := buildGraph(ctx, org, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.ResolveConflicts(conflictStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock the IDs the graph resolved to, the 18 character ID of a version typed as 15 characters
+	for _, ref := range refs {
+		if n := g.lookup(lock.Requests[ref]); n != nil {
+			lock.Requests[ref] = n.ID
+		}
+	}
+
+	if !save {
+		return g, nil
+	}
+
+	if err := lock.add(ctx, g); err != nil {
+		return nil, err
+	}
+	lock.prune()
+
+	err = lock.write()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(out, "Updated %s\n", lockFileName)
+	return g, nil
+}
+
+// frozenGraph resolves the dependency graph of the locked 04t IDs and fails when it differs from
+// the graph locked in the lockfile, as installing would then change the lockfile.
+func frozenGraph(ctx context.Context, org string, lock *Lockfile, ids []string) (*Graph, error) {
+	locked, err := lock.graph(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := buildGraph(ctx, org, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.ResolveConflicts(conflictStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes := graphChanges(locked, g); len(changes) > 0 {
+		return nil, fmt.Errorf("%w: installing would change %s:\n  %s", ErrLockfileOutdated, lockFileName, strings.Join(changes, "\n  "))
+	}
+
+	return g, nil
+}
+
+// graphChanges describes the package versions added to or removed from the locked graph,
+// and those whose dependencies changed, one line each.
+func graphChanges(locked *Graph, resolved *Graph) []string {
+	var changes []string
+
+	for _, n := range resolved.Nodes() {
+		l := locked.Node(n.ID)
+		if l == nil {
+			changes = append(changes, fmt.Sprintf("+ %s - %s", n, n.ID))
+			continue
+		}
+
+		if !sameNodes(l.Dependencies, n.Dependencies) {
+			changes = append(changes, fmt.Sprintf("~ %s - %s dependencies changed", n, n.ID))
+		}
+	}
+
+	for _, l := range locked.Nodes() {
+		if resolved.Node(l.ID) == nil {
+			changes = append(changes, fmt.Sprintf("- %s - %s", l, l.ID))
+		}
+	}
+
+	return changes
+}
+
+// sameNodes reports whether both lists hold nodes with the same IDs, in any order.
+func sameNodes(a []*Node, b []*Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	ids := make(map[string]bool)
+	for _, n := range a {
+		ids[n.ID] = true
+	}
+
+	for _, n := range b {
+		if !ids[n.ID] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// add locks every node of the graph.
+func (l *Lockfile) add(ctx context.Context, g *Graph) error {
+	// Only packages owned by the dev hub have a 0Ho ID, so a project without
+	// a dev hub simply locks its packages without one
+	if err := getPkgVersions(ctx); err != nil && !errors.Is(err, ErrNoDefaultDevHub) {
+		return err
+	}
+
+	for _, n := range g.Nodes() {
+		locked := &LockedPackage{
+			Name:    n.Name,
+			Version: n.Version,
+		}
+
+		if n.Pkg != nil {
+			locked.SubscriberPackageID = n.Pkg.PackageID
+			locked.PackageType = n.Pkg.PackageType
+			locked.Beta = n.Pkg.IsBeta
+		}
+
+		for _, ver := range pkgVersions {
+			if sameID(ver.ID, n.ID) {
+				locked.PackageID = ver.PackageID
+				break
+			}
+		}
+
+		for _, dep := range n.Dependencies {
+			locked.Dependencies = append(locked.Dependencies, dep.ID)
+		}
+
+		l.Packages[n.ID] = locked
+	}
+
+	return nil
+}
+
+// prune removes the packages no longer reachable from any request.
+func (l *Lockfile) prune() {
+	reachable := make(map[string]bool)
+
+	var visit func(id string)
+	visit = func(id string) {
+		locked, ok := l.Packages[id]
+		if !ok || reachable[id] {
+			return
+		}
+
+		reachable[id] = true
+		for _, dep := range locked.Dependencies {
+			visit(dep)
+		}
+	}
+
+	for _, id := range l.Requests {
+		visit(id)
+	}
+
+	for id := range l.Packages {
+		if !reachable[id] {
+			delete(l.Packages, id)
+		}
+	}
+}
+
+// graph builds the dependency graph of the locked 04t IDs without querying any org.
+func (l *Lockfile) graph(ids []string) (*Graph, error) {
+	g := NewGraph()
+
+	var add func(id string) (*Node, error)
+	add = func(id string) (*Node, error) {
+		if n := g.Node(id); n != nil {
+			return n, nil
+		}
+
+		locked, ok := l.Packages[id]
+		if !ok {
+			// Versions are locked by their 18 character ID
+			for lockedID, p := range l.Packages {
+				if sameID(lockedID, id) {
+					id, locked, ok = lockedID, p, true
+					break
+				}
+			}
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("%w: package version %s is not locked", ErrLockfileOutdated, id)
+		}
+
+		pkv, err := locked.subscriberPkgVersion(id)
+		if err != nil {
+			return nil, err
+		}
+
+		n := g.AddNode(&Node{ID: id, Name: locked.Name, Version: locked.Version, Pkg: pkv})
+		for _, depID := range locked.Dependencies {
+			dep, err := add(depID)
+			if err != nil {
+				return nil, err
+			}
+
+			g.AddEdge(n, dep)
+		}
+
+		return n, nil
+	}
+
+	for _, id := range ids {
+		n, err := add(id)
+		if err != nil {
+			return nil, err
+		}
+
+		g.AddRoot(n)
+	}
+
+	return g, nil
+}
+
+// subscriberPkgVersion rebuilds the SubscriberPkgVersion the package was locked from.
+func (p *LockedPackage) subscriberPkgVersion(id string) (*SubscriberPkgVersion, error) {
+	num, err := ParsePackageVersionNumber(p.Version)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s version for %s: %v", lockFileName, id, err)
+	}
+
+	pkv := &SubscriberPkgVersion{
+		ID:           id,
+		Name:         p.Name,
+		PackageID:    p.SubscriberPackageID,
+		MajorVersion: num.Major,
+		MinorVersion: num.Minor,
+		PatchVersion: num.Patch,
+		BuildNumber:  num.Build,
+		PackageType:  p.PackageType,
+		IsBeta:       p.Beta,
+	}
+
+	for _, dep := range p.Dependencies {
+		pkv.Dependencies.Ids = append(pkv.Dependencies.Ids, subscriberPackageDependency{SubscriberPackageVersionID: dep})
+	}
+
+	return pkv, nil
+}
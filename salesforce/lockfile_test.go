@@ -0,0 +1,163 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const lockTestQuery = "data query --target-org user1@example.com --use-tooling-api --query "
+
+// lockTestRunner serves Base 1.0, and Extension 2.0 which depends on Base in the org.
+func lockTestRunner() *stubRunner {
+	return &stubRunner{outputs: map[string]string{
+		lockTestQuery + subscriberPkgVersionFields + " WHERE Id IN ('04t000000000002AAA')": `{"status":0,"result":{"done":true,"records":[
+			{"Id":"04t000000000002AAA","SubscriberPackageId":"033000000000002AAA","MajorVersion":2,"MinorVersion":0,"PatchVersion":0,"BuildNumber":1,"Dependencies":{"ids":[{"subscriberPackageVersionId":"04t000000000001AAA"}]}}]}}`,
+		lockTestQuery + subscriberPkgVersionFields + " WHERE Id IN ('04t000000000001AAA')": `{"status":0,"result":{"done":true,"records":[
+			{"Id":"04t000000000001AAA","SubscriberPackageId":"033000000000001AAA","MajorVersion":1,"MinorVersion":0,"PatchVersion":0,"BuildNumber":1}]}}`,
+		lockTestQuery + "SELECT Id, Name FROM SubscriberPackage WHERE Id IN ('033000000000002AAA')": `{"status":0,"result":{"done":true,"records":[{"Id":"033000000000002AAA","Name":"Extension"}]}}`,
+		lockTestQuery + "SELECT Id, Name FROM SubscriberPackage WHERE Id IN ('033000000000001AAA')": `{"status":0,"result":{"done":true,"records":[{"Id":"033000000000001AAA","Name":"Base"}]}}`,
+		"package version list": `{"status":1,"name":"NoDefaultDevHubError","message":"No default dev hub found."}`,
+	}}
+}
+
+func useLockTestRunner(t *testing.T, r Runner) {
+	t.Helper()
+
+	SetRunner(r)
+	SetOutput(ioutil.Discard)
+	SetConflictStrategy(StrategyHighest)
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	EnableAPI(false)
+	t.Cleanup(func() {
+		SetRunner(&ExecRunner{})
+		SetOutput(os.Stdout)
+		EnableAPI(true)
+	})
+}
+
+func TestFrozenGraph(t *testing.T) {
+	base := &LockedPackage{Name: "Base", SubscriberPackageID: "033000000000001AAA", Version: "1.0.0.1"}
+
+	tests := []struct {
+		name     string
+		packages map[string]*LockedPackage
+		changes  []string
+	}{
+		{
+			name: "unchanged",
+			packages: map[string]*LockedPackage{
+				"04t000000000002AAA": {Name: "Extension", SubscriberPackageID: "033000000000002AAA", Version: "2.0.0.1", Dependencies: []string{"04t000000000001AAA"}},
+				"04t000000000001AAA": base,
+			},
+		},
+		{
+			name: "dependency added",
+			packages: map[string]*LockedPackage{
+				"04t000000000002AAA": {Name: "Extension", SubscriberPackageID: "033000000000002AAA", Version: "2.0.0.1"},
+			},
+			changes: []string{"~ Extension 2.0.0.1 - 04t000000000002AAA dependencies changed", "+ Base 1.0.0.1 - 04t000000000001AAA"},
+		},
+		{
+			name: "dependency removed",
+			packages: map[string]*LockedPackage{
+				"04t000000000002AAA": {Name: "Extension", SubscriberPackageID: "033000000000002AAA", Version: "2.0.0.1", Dependencies: []string{"04t000000000001AAA", "04t000000000003AAA"}},
+				"04t000000000001AAA": base,
+				"04t000000000003AAA": {Name: "Old", SubscriberPackageID: "033000000000003AAA", Version: "1.0.0.1"},
+			},
+			changes: []string{"~ Extension 2.0.0.1 - 04t000000000002AAA dependencies changed", "- Old 1.0.0.1 - 04t000000000003AAA"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useLockTestRunner(t, lockTestRunner())
+
+			lock := newLockfile()
+			lock.Requests["Extension"] = "04t000000000002AAA"
+			lock.Packages = tt.packages
+
+			g, err := frozenGraph(context.Background(), "user1@example.com", lock, []string{"04t000000000002AAA"})
+			if len(tt.changes) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(g.Nodes()) != 2 {
+					t.Errorf("frozenGraph() nodes = %v, want Extension and Base", g.Nodes())
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrLockfileOutdated) {
+				t.Fatalf("frozenGraph() error = %v, want ErrLockfileOutdated", err)
+			}
+
+			for _, change := range tt.changes {
+				if !strings.Contains(err.Error(), change) {
+					t.Errorf("frozenGraph() error = %v, want it to list %q", err, change)
+				}
+			}
+		})
+	}
+}
+
+func TestLockfileAdd(t *testing.T) {
+	g := NewGraph()
+	ext := g.AddRoot(testNode("04t000000000002AAA", "Extension", "2.0.0.1", false))
+	g.AddEdge(ext, g.AddNode(testNode("04t000000000001AAA", "Base", "1.0.0.1", false)))
+
+	// Without a dev hub packages are locked without their 0Ho ID
+	useLockTestRunner(t, lockTestRunner())
+
+	lock := newLockfile()
+	if err := lock.add(context.Background(), g); err != nil {
+		t.Fatal(err)
+	}
+
+	locked := lock.Packages["04t000000000002AAA"]
+	if locked == nil || locked.Name != "Extension" || len(locked.Dependencies) != 1 || locked.Dependencies[0] != "04t000000000001AAA" {
+		t.Errorf("Packages[04t000000000002AAA] = %+v, want Extension depending on Base", locked)
+	}
+
+	// Other failures listing the dev hub's packages are returned
+	useLockTestRunner(t, &stubRunner{outputs: map[string]string{
+		"package version list": `{"status":1,"name":"RefreshTokenAuthError","message":"Error authenticating with the refresh token due to: expired access/refresh token"}`,
+	}})
+
+	if err := newLockfile().add(context.Background(), g); !errors.Is(err, ErrAuthExpired) {
+		t.Errorf("add() error = %v, want ErrAuthExpired", err)
+	}
+}
+
+func TestLockedGraphShortID(t *testing.T) {
+	r := lockTestRunner()
+	r.outputs["org list"] = `{"status":0,"result":{"nonScratchOrgs":[],"scratchOrgs":[]}}`
+	r.outputs[lockTestQuery+subscriberPkgVersionFields+" WHERE Id IN ('04t000000000002')"] = r.outputs[lockTestQuery+subscriberPkgVersionFields+" WHERE Id IN ('04t000000000002AAA')"]
+	useLockTestRunner(t, r)
+	useTestProject(t, `{"packageDirectories": [{"path": "force-app", "default": true}]}`)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		g, err := lockedGraph(ctx, "user1@example.com", []string{"04t000000000002"}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n := g.Node("04t000000000002"); n == nil || n.ID != "04t000000000002AAA" || len(g.Roots) != 1 || g.Roots[0] != n {
+			t.Fatalf("lockedGraph() roots = %v, want Extension 04t000000000002AAA", g.Roots)
+		}
+
+		lock, err := readLockfile()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if id := lock.Requests["04t000000000002"]; id != "04t000000000002AAA" {
+			t.Errorf("Requests[04t000000000002] = %s, want the 18 character ID", id)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// pinDependencies saves installed packages in their pinned form, with a versionNumber and
+// version aliases, instead of a package alias naming the installed 04t version.
+var pinDependencies bool
+
+// SetPinDependencies sets whether installed packages are saved to sfdx-project.json in their pinned form.
+func SetPinDependencies(pin bool) {
+	pinDependencies = pin
+}
+
+// PinDependencies converts the project's dependencies to their pinned form, the form sfdx needs to build
+// package versions. A dev hub package is declared by name and versionNumber, with a Name alias naming its
+// 0Ho package and a Name@Major.Minor.Patch-Build alias naming the 04t version. Unlocked packages are pinned
+// to the LATEST build of the version, managed packages to the exact build. Packages the dev hub does not
+// own are declared by their version alias. Only the dependencies of the package directory matched by path
+// or package name are converted when dir is set, and only those on pkg when pkg is set. Package directories
+// share the Name alias, so nothing is converted while another directory declares the package in the other form.
+func PinDependencies(ctx context.Context, org string, dir string, pkg string) error {
+	return convertDependencies(ctx, org, dir, pkg, true)
+}
+
+// UnpinDependencies converts the project's dependencies to their floating form, declared by
+// name with a Name alias naming the locked, or otherwise resolved, 04t version.
+func UnpinDependencies(ctx context.Context, org string, dir string, pkg string) error {
+	return convertDependencies(ctx, org, dir, pkg, false)
+}
+
+func convertDependencies(ctx context.Context, org string, dir string, pkg string, pin bool) error {
+	if err := CheckCli(); err != nil {
+		return err
+	}
+
+	if err := CheckSFDX(); err != nil {
+		return err
+	}
+
+	org, err := resolveOrg(ctx, org)
+	if err != nil {
+		return err
+	}
+
+	proj, err := readProject()
+	if err != nil {
+		return err
+	}
+
+	file, err := openProjectFile()
+	if err != nil {
+		return err
+	}
+
+	if len(proj.PackageDirectories) == 0 {
+		return errors.New("The project does not contain any packageDirectories")
+	}
+
+	var dirs []*jsonObject
+	if len(dir) > 0 {
+		i, err := selectPackageDirectory(proj, dir)
+		if err != nil {
+			return err
+		}
+
+		dirs = append(dirs, file.packageDirectory(i))
+	} else {
+		for i := range proj.PackageDirectories {
+			dirs = append(dirs, file.packageDirectory(i))
+		}
+	}
+
+	lock, err := readLockfile()
+	if err != nil {
+		return err
+	}
+
+	beta, err := allowBeta(ctx, org)
+	if err != nil {
+		return err
+	}
+
+	own := make(map[string]bool)
+	for _, d := range proj.PackageDirectories {
+		own[d.PackageName] = true
+	}
+
+	var converted []string
+	for _, d := range dirs {
+		if d == nil {
+			return fmt.Errorf("Invalid %s: packageDirectories must be objects", projectFileName)
+		}
+
+		for _, dep := range dependencies(d) {
+			name := dep.getString("package")
+			if own[name] {
+				continue
+			}
+
+			// Only package IDs need the dependency resolved to tell whether it is on pkg
+			if len(pkg) > 0 && !isPackageID(pkg) && !dependsOn(proj, name, pkg) && !isPackageID(projectName(proj, name)) {
+				continue
+			}
+
+			ref := dependencyRef(proj, SfdxProjectDependency{PackageName: name, VersionNumber: dep.getString("versionNumber")})
+
+			// Keep the version the project is locked to rather than resolving a newer one
+			id, ok := "", false
+			if lock != nil {
+				id, ok = lock.Requests[ref]
+			}
+
+			if !ok {
+				id, err = resolvePkgVersionID(ctx, org, ref, beta)
+				if err != nil {
+					return err
+				}
+			}
+
+			ver, err := getSubscriberPkgVersion(ctx, org, id)
+			if err != nil {
+				return err
+			}
+
+			if ver == nil {
+				return fmt.Errorf("%w: %s", ErrPackageNotFound, id)
+			}
+
+			if len(pkg) > 0 && !dependsOn(proj, name, pkg) && !sameID(pkg, id) && !strings.EqualFold(pkg, ver.Name) {
+				continue
+			}
+
+			if err := writeDependency(ctx, file, dep, ver, pin); err != nil {
+				return err
+			}
+
+			converted = append(converted, ver.Name)
+			if pin {
+				fmt.Fprintf(out, "Pinned %s to %s\n", ver.Name, dependencyVersion(file, dep))
+			} else {
+				fmt.Fprintf(out, "Unpinned %s at %s - %s\n", ver.Name, ver.VersionNumber(), ver.ID)
+			}
+		}
+	}
+
+	if len(pkg) > 0 && len(converted) == 0 {
+		return fmt.Errorf("%w: the project does not depend on %s", ErrPackageNotFound, pkg)
+	}
+
+	for _, name := range converted {
+		if err := checkNameAlias(file, name); err != nil {
+			return err
+		}
+	}
+
+	return file.save()
+}
+
+// writeDependency declares dep as the subscriber package version, in its pinned form when pin is set,
+// and removes the aliases of the package no dependency references anymore.
+func writeDependency(ctx context.Context, p *projectFile, dep *jsonObject, ver *SubscriberPkgVersion, pin bool) error {
+	aliases := p.packageAliases()
+	keep := ver.Name
+
+	if !pin {
+		dep.set("package", ver.Name)
+		dep.delete("versionNumber")
+		aliases.set(ver.Name, ver.ID)
+		pruneAliases(p, ver.Name, keep)
+
+		return nil
+	}
+
+	packageID, err := devHubPackageID(ctx, ver.ID)
+	if err != nil {
+		return err
+	}
+
+	key := versionAlias(ver.Name, ver.VersionNumber())
+	aliases.set(key, ver.ID)
+
+	if len(packageID) > 0 {
+		dep.set("package", ver.Name)
+		dep.set("versionNumber", pinnedVersionNumber(ver))
+		aliases.set(ver.Name, packageID)
+	} else {
+		// sfdx can only resolve packages from outside the dev hub by a version alias
+		dep.set("package", key)
+		dep.delete("versionNumber")
+		keep = key
+	}
+
+	pruneAliases(p, ver.Name, keep, key)
+	return nil
+}
+
+// isPinned reports whether the dependency is declared in its pinned form, with a versionNumber or by version alias.
+func isPinned(dep *jsonObject) bool {
+	return len(dep.getString("versionNumber")) > 0 || strings.Contains(dep.getString("package"), "@")
+}
+
+// checkNameAlias returns an error when a dependency declaring the package by name no longer suits
+// the package's Name alias. Every package directory shares the alias, which names the 0Ho package
+// for dependencies with a versionNumber and the 04t version for dependencies without one.
+func checkNameAlias(p *projectFile, name string) error {
+	alias := p.packageAliases().getString(name)
+	pinned := strings.HasPrefix(alias, packagePrefix)
+	if !pinned && !strings.HasPrefix(alias, versionPrefix) {
+		return nil
+	}
+
+	for i := 0; p.packageDirectory(i) != nil; i++ {
+		dir := p.packageDirectory(i)
+		for _, dep := range dependencies(dir) {
+			if dep.getString("package") != name || (len(dep.getString("versionNumber")) > 0) == pinned {
+				continue
+			}
+
+			if pinned {
+				return fmt.Errorf("%s is also declared without a versionNumber by package directory %s, which shares its %s alias. Pin the dependency there too", name, dir.getString("path"), name)
+			}
+
+			return fmt.Errorf("%s is also declared with a versionNumber by package directory %s, which shares its %s alias. Unpin the dependency there too", name, dir.getString("path"), name)
+		}
+	}
+
+	return nil
+}
+
+// dependsOn reports whether the dependency declared as ref is on pkg, by package name, version alias or ID.
+func dependsOn(proj *SfdxProject, ref string, pkg string) bool {
+	if isPackageID(pkg) && (sameID(ref, pkg) || sameID(proj.PackageAliases[ref], pkg)) {
+		return true
+	}
+
+	return strings.EqualFold(projectName(proj, ref), pkg)
+}
+
+// projectName returns the name of the package the dependency declared as ref is on, or its ID when the project has no alias for it.
+func projectName(proj *SfdxProject, ref string) string {
+	name, _ := projectPackageName(proj, ref)
+	return name
+}
+
+// isPackageID reports whether ref is a 0Ho package or 04t package version ID.
+func isPackageID(ref string) bool {
+	return strings.HasPrefix(ref, packagePrefix) || strings.HasPrefix(ref, versionPrefix)
+}
+
+// findDependency returns the package directory's dependency on the package, declared by name
+// or by one of its Name@Major.Minor.Patch-Build version aliases, or nil.
+func findDependency(dir *jsonObject, name string) *jsonObject {
+	for _, dep := range dependencies(dir) {
+		pkg := dep.getString("package")
+		if pkg == name || strings.HasPrefix(pkg, name+"@") {
+			return dep
+		}
+	}
+
+	return nil
+}
+
+// pruneAliases removes the Name and Name@ version aliases of the package which no
+// dependency in any package directory references, except those listed in keep.
+func pruneAliases(p *projectFile, name string, keep ...string) {
+	referenced := make(map[string]bool)
+	for _, k := range keep {
+		referenced[k] = true
+	}
+
+	for i := 0; p.packageDirectory(i) != nil; i++ {
+		for _, dep := range dependencies(p.packageDirectory(i)) {
+			referenced[dep.getString("package")] = true
+		}
+	}
+
+	aliases := p.packageAliases()
+	for _, key := range append([]string(nil), aliases.keys...) {
+		if (key == name || strings.HasPrefix(key, name+"@")) && !referenced[key] {
+			aliases.delete(key)
+		}
+	}
+}
+
+// dependencyVersion describes the version dep is pinned to.
+func dependencyVersion(p *projectFile, dep *jsonObject) string {
+	if num := dep.getString("versionNumber"); len(num) > 0 {
+		return num
+	}
+
+	pkg := dep.getString("package")
+	return pkg + " (" + p.packageAliases().getString(pkg) + ")"
+}
+
+// devHubPackageID returns the 0Ho ID of the dev hub package of the 04t version ID,
+// or an empty ID when the default dev hub does not own the package.
+func devHubPackageID(ctx context.Context, id string) (string, error) {
+	ver, err := getPkgVersion(ctx, id)
+	if errors.Is(err, ErrPackageNotFound) || errors.Is(err, ErrNoDefaultDevHub) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return ver.PackageID, nil
+}
+
+// versionAlias returns the sfdx alias Name@Major.Minor.Patch-Build of the package version.
+func versionAlias(name string, v PackageVersionNumber) string {
+	return fmt.Sprintf("%s@%d.%d.%d-%d", name, v.Major, v.Minor, v.Patch, v.Build)
+}
+
+// pinnedVersionNumber returns the versionNumber a dependency on the package version is pinned to,
+// the exact build of managed packages and the LATEST build of unlocked packages.
+func pinnedVersionNumber(ver *SubscriberPkgVersion) string {
+	v := ver.VersionNumber()
+	if ver.PackageType == managedPackageType {
+		return v.String()
+	}
+
+	return fmt.Sprintf("%d.%d.%d.%s", v.Major, v.Minor, v.Patch, latestVersion)
+}
@@ -0,0 +1,100 @@
+package salesforce
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUpsertDependencyKeepsPinnedForm(t *testing.T) {
+	useLockTestRunner(t, lockTestRunner())
+	useTestProject(t, `{"packageDirectories": [
+		{"path": "force-app", "default": true, "dependencies": [{"package": "Base@0.9.0-1"}]}
+	], "packageAliases": {"Base@0.9.0-1": "04t000000000009AAA"}}`)
+
+	if err := upsertDependencyToProjectFile(context.Background(), "user1@example.com", "04t000000000001AAA"); err != nil {
+		t.Fatal(err)
+	}
+
+	proj, err := readProject()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := proj.PackageDirectories[0].Dependencies
+	if len(deps) != 1 || deps[0].PackageName != "Base@1.0.0-1" {
+		t.Errorf("dependencies = %+v, want Base@1.0.0-1", deps)
+	}
+
+	if len(proj.PackageAliases) != 1 || proj.PackageAliases["Base@1.0.0-1"] != "04t000000000001AAA" {
+		t.Errorf("packageAliases = %v, want only Base@1.0.0-1", proj.PackageAliases)
+	}
+}
+
+func TestCheckNameAlias(t *testing.T) {
+	tests := []struct {
+		name  string
+		alias string
+		err   string
+	}{
+		{name: "pinned", alias: "0Ho000000000001AAA", err: "without a versionNumber by package directory extra"},
+		{name: "floating", alias: "04t000000000001AAA", err: "with a versionNumber by package directory force-app"},
+		{name: "version alias only", alias: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aliases := `"Base@1.0.0-1": "04t000000000001AAA"`
+			if len(tt.alias) > 0 {
+				aliases += `, "Base": "` + tt.alias + `"`
+			}
+
+			useTestProject(t, `{"packageDirectories": [
+				{"path": "force-app", "default": true, "dependencies": [{"package": "Base", "versionNumber": "1.0.0.LATEST"}]},
+				{"path": "extra", "dependencies": [{"package": "Base"}, {"package": "Base@1.0.0-1"}]}
+			], "packageAliases": {`+aliases+`}}`)
+
+			p, err := openProjectFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = checkNameAlias(p, "Base")
+			switch {
+			case len(tt.err) == 0 && err != nil:
+				t.Errorf("checkNameAlias() error = %v", err)
+			case len(tt.err) > 0 && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("checkNameAlias() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDependsOn(t *testing.T) {
+	proj := &SfdxProject{PackageAliases: map[string]string{
+		"Base":         "0Ho000000000001AAA",
+		"Base@1.0.0-1": "04t000000000001AAA",
+		"Other":        "04t000000000002AAA",
+	}}
+
+	tests := []struct {
+		ref  string
+		pkg  string
+		want bool
+	}{
+		{ref: "Base", pkg: "base", want: true},
+		{ref: "Base@1.0.0-1", pkg: "Base", want: true},
+		{ref: "Base@1.0.0-1", pkg: "04t000000000001", want: true},
+		{ref: "Other", pkg: "04t000000000002", want: true},
+		{ref: "04t000000000002", pkg: "Other", want: true},
+		{ref: "04t000000000002AAA", pkg: "04t000000000002", want: true},
+		{ref: "Other", pkg: "Base", want: false},
+		{ref: "Base@1.0.0-1", pkg: "04t000000000002AAA", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := dependsOn(proj, tt.ref, tt.pkg); got != tt.want {
+			t.Errorf("dependsOn(%s, %s) = %t, want %t", tt.ref, tt.pkg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package salesforce
+
+import "sync"
+
+// installProgress tracks which packages an install has completed and which are still pending.
+type installProgress struct {
+	mu        sync.Mutex
+	completed []string
+	pending   []string
+	labels    map[string]string
+}
+
+var progress = &installProgress{labels: make(map[string]string)}
+
+// InstallProgress returns the packages installed so far and those still
+// waiting to be installed, in the order they were discovered.
+func InstallProgress() (completed []string, pending []string) {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	completed = append(completed, progress.completed...)
+	for _, id := range progress.pending {
+		pending = append(pending, progress.label(id))
+	}
+
+	return completed, pending
+}
+
+// addPending records a package version as waiting to be installed.
+func (p *installProgress) addPending(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.indexOf(id) >= 0 {
+		return
+	}
+
+	p.pending = append(p.pending, id)
+}
+
+// setLabel records a readable name for a package version.
+func (p *installProgress) setLabel(id string, label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.labels[id] = label
+}
+
+// complete moves a package version from pending to completed.
+func (p *installProgress) complete(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if i := p.indexOf(id); i >= 0 {
+		p.pending = append(p.pending[:i], p.pending[i+1:]...)
+	}
+
+	p.completed = append(p.completed, p.label(id))
+}
+
+func (p *installProgress) indexOf(id string) int {
+	for i, pending := range p.pending {
+		if pending == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (p *installProgress) label(id string) string {
+	if label, ok := p.labels[id]; ok {
+		return label
+	}
+
+	return id
+}
@@ -0,0 +1,209 @@
+package salesforce
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// packageDirectory selects the packageDirectory installed packages are saved to
+// and uninstalled packages are removed from, the default directory when empty.
+var packageDirectory string
+
+// SetPackageDirectory selects the packageDirectory, by path or package name, that
+// installed packages are saved to and uninstalled packages are removed from.
+func SetPackageDirectory(dir string) {
+	packageDirectory = dir
+}
+
+// readProject reads the located sfdx-project.json file.
+func readProject() (*SfdxProject, error) {
+	data, err := ioutil.ReadFile(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var proj SfdxProject
+	err = json.Unmarshal(data, &proj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proj, nil
+}
+
+// ProjectGraph builds the dependency graph declared by the project's packageDirectories,
+// without querying any org. Each package directory is a root. Dependencies naming a package,
+// one of its Name@Major.Minor.Patch-Build version aliases or an aliased ID all resolve to the
+// same node, identified by the package's 0Ho alias, or its name when it has none.
+func ProjectGraph() (*Graph, error) {
+	if err := CheckSFDX(); err != nil {
+		return nil, err
+	}
+
+	proj, err := readProject()
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewGraph()
+	node := func(ref string, version string) *Node {
+		name, aliasVersion := projectPackageName(proj, ref)
+		if len(version) == 0 {
+			version = aliasVersion
+		}
+
+		id := name
+		if alias := proj.PackageAliases[name]; strings.HasPrefix(alias, packagePrefix) {
+			id = alias
+		}
+
+		n := g.AddNode(&Node{ID: id, Name: name})
+		if len(n.Version) == 0 {
+			n.Version = version
+		}
+
+		return n
+	}
+
+	for _, dir := range proj.PackageDirectories {
+		if len(dir.PackageName) == 0 {
+			continue
+		}
+
+		// A package directory's own version wins over the versions its dependents ask for
+		n := g.AddRoot(node(dir.PackageName, ""))
+		n.Version = dir.VersionNumber
+
+		for _, dep := range dir.Dependencies {
+			g.AddEdge(n, node(dep.PackageName, dep.VersionNumber))
+		}
+	}
+
+	return g, nil
+}
+
+// projectPackageName returns the name of the package a dependency refers to, by name, version
+// alias or aliased 0Ho or 04t ID, and the version a Name@Major.Minor.Patch-Build alias names.
+func projectPackageName(proj *SfdxProject, ref string) (string, string) {
+	if strings.HasPrefix(ref, packagePrefix) || strings.HasPrefix(ref, versionPrefix) {
+		keys := make([]string, 0, len(proj.PackageAliases))
+		for key := range proj.PackageAliases {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if sameID(proj.PackageAliases[key], ref) {
+				ref = key
+				break
+			}
+		}
+	}
+
+	if i := strings.LastIndex(ref, "@"); i > 0 {
+		return ref[:i], ref[i+1:]
+	}
+
+	return ref, ""
+}
+
+// projectDependencyRefs returns a package reference for each distinct dependency declared
+// by the project's packageDirectories, leaving out the project's own packages.
+func projectDependencyRefs(proj *SfdxProject) []string {
+	var refs []string
+	for _, dir := range proj.PackageDirectories {
+		for _, ref := range directoryDependencyRefs(proj, dir) {
+			if !contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	return refs
+}
+
+// directoryDependencyRefs returns a package reference for each dependency declared by
+// the package directory, leaving out the project's own packages.
+func directoryDependencyRefs(proj *SfdxProject, dir SfdxPackageDirectory) []string {
+	own := make(map[string]bool)
+	for _, d := range proj.PackageDirectories {
+		if len(d.PackageName) > 0 {
+			own[d.PackageName] = true
+		}
+	}
+
+	var refs []string
+	for _, dep := range dir.Dependencies {
+		if own[dep.PackageName] {
+			continue
+		}
+
+		ref := dependencyRef(proj, dep)
+		if !contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// selectPackageDirectory returns the index of the package directory matching dir by path or
+// package name, or of the directory marked default when dir is empty.
+func selectPackageDirectory(proj *SfdxProject, dir string) (int, error) {
+	if len(proj.PackageDirectories) == 0 {
+		return 0, errors.New("The project does not contain any packageDirectories")
+	}
+
+	if len(dir) == 0 {
+		for i, d := range proj.PackageDirectories {
+			if d.Default {
+				return i, nil
+			}
+		}
+
+		if len(proj.PackageDirectories) == 1 {
+			return 0, nil
+		}
+
+		return 0, errors.New("No packageDirectory is marked as the default, choose one with --dir")
+	}
+
+	for i, d := range proj.PackageDirectories {
+		if filepath.Clean(d.Path) == filepath.Clean(dir) || strings.EqualFold(d.PackageName, dir) {
+			return i, nil
+		}
+	}
+
+	var names []string
+	for _, d := range proj.PackageDirectories {
+		names = append(names, d.Path)
+	}
+
+	return 0, fmt.Errorf("No packageDirectory with the path or package %s, found: %s", dir, strings.Join(names, ", "))
+}
+
+// dependencyRef returns the package reference of a dependency, its aliased 04t ID when it has
+// one and otherwise its package name, or aliased 0Ho ID, constrained to its versionNumber.
+func dependencyRef(proj *SfdxProject, dep SfdxProjectDependency) string {
+	name := dep.PackageName
+	if alias, ok := proj.PackageAliases[name]; ok {
+		if strings.HasPrefix(alias, versionPrefix) {
+			return alias
+		}
+
+		if strings.HasPrefix(alias, packagePrefix) {
+			name = alias
+		}
+	}
+
+	if len(dep.VersionNumber) == 0 {
+		return name
+	}
+
+	return name + "@" + dep.VersionNumber
+}
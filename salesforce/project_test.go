@@ -0,0 +1,108 @@
+package salesforce
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// useTestProject writes the sfdx-project.json to a temporary project and locates it.
+func useTestProject(t *testing.T, project string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), projectFileName)
+	if err := ioutil.WriteFile(path, []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	projectPath = path
+	t.Cleanup(func() { projectPath = "" })
+
+	return path
+}
+
+func TestProjectGraphCycles(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		cycle   bool
+	}{
+		{
+			name: "no cycle",
+			project: `{"packageDirectories": [
+				{"path": "core", "package": "Core", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "Base"}]},
+				{"path": "app", "package": "App", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "Base"}, {"package": "Core", "versionNumber": "1.0.0.LATEST"}]}
+			], "packageAliases": {"Base": "04t000000000001AAA", "Core": "0Ho000000000001AAA"}}`,
+		},
+		{
+			name: "by name",
+			project: `{"packageDirectories": [
+				{"path": "core", "package": "Core", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "App"}]},
+				{"path": "app", "package": "App", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "Core"}]}
+			]}`,
+			cycle: true,
+		},
+		{
+			name: "by version alias",
+			project: `{"packageDirectories": [
+				{"path": "core", "package": "Core", "versionNumber": "1.2.0.NEXT", "dependencies": [{"package": "App@1.0.0-1"}]},
+				{"path": "app", "package": "App", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "Core", "versionNumber": "1.2.0.LATEST"}]}
+			], "packageAliases": {"Core": "0Ho000000000001AAA", "App": "0Ho000000000002AAA", "App@1.0.0-1": "04t000000000002AAA"}}`,
+			cycle: true,
+		},
+		{
+			name: "by aliased ID",
+			project: `{"packageDirectories": [
+				{"path": "core", "package": "Core", "versionNumber": "1.2.0.NEXT", "dependencies": [{"package": "04t000000000002"}]},
+				{"path": "app", "package": "App", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "0Ho000000000001AAA", "versionNumber": "1.2.0.LATEST"}]}
+			], "packageAliases": {"Core": "0Ho000000000001AAA", "App": "0Ho000000000002AAA", "App@1.0.0-1": "04t000000000002AAA"}}`,
+			cycle: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestProject(t, tt.project)
+
+			g, err := ProjectGraph()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = g.InstallOrder()
+
+			var cycle *CycleError
+			switch {
+			case !tt.cycle && err != nil:
+				t.Errorf("InstallOrder() error = %v, want no cycle", err)
+			case tt.cycle && !errors.As(err, &cycle):
+				t.Errorf("InstallOrder() error = %v, want a cycle", err)
+			case tt.cycle && len(cycle.Path) != 3:
+				t.Errorf("InstallOrder() error = %v, want the cycle between Core and App", err)
+			}
+		})
+	}
+}
+
+func TestProjectGraphSharesNodes(t *testing.T) {
+	useTestProject(t, `{"packageDirectories": [
+		{"path": "core", "package": "Core", "versionNumber": "1.2.0.NEXT"},
+		{"path": "app", "package": "App", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "Core@1.2.0-4"}]},
+		{"path": "extra", "package": "Extra", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "Core", "versionNumber": "1.2.0.LATEST"}]}
+	], "packageAliases": {"Core": "0Ho000000000001AAA", "Core@1.2.0-4": "04t000000000004AAA"}}`)
+
+	g, err := ProjectGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(g.Nodes()); got != 3 {
+		t.Errorf("len(Nodes()) = %d, want 3", got)
+	}
+
+	core := g.Node("0Ho000000000001AAA")
+	if core == nil || core.Name != "Core" || core.Version != "1.2.0.NEXT" {
+		t.Errorf("Node(0Ho000000000001AAA) = %+v, want Core 1.2.0.NEXT", core)
+	}
+}
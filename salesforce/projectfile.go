@@ -0,0 +1,465 @@
+package salesforce
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// projectFile is an editable sfdx-project.json. Only the entries dxpm changes are touched,
+// every other value is written back exactly as it was in the file, and the order of keys is
+// kept. Objects and arrays dxpm adds or changes are written one entry per line with the
+// file's indentation.
+type projectFile struct {
+	path string
+	mode os.FileMode
+	root *jsonObject
+
+	indent          string
+	crlf            bool
+	trailingNewline bool
+}
+
+// openProjectFile reads the located sfdx-project.json for editing.
+func openProjectFile() (*projectFile, error) {
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Line endings are restored when saving
+	crlf := bytes.Contains(data, []byte("\r\n"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	value, err := decodeJSONValue(dec, data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s: %v", projectFileName, err)
+	}
+
+	root, ok := value.(*jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s: expected an object", projectFileName)
+	}
+
+	return &projectFile{
+		path:            projectPath,
+		mode:            info.Mode().Perm(),
+		root:            root,
+		indent:          detectIndent(data),
+		crlf:            crlf,
+		trailingNewline: bytes.HasSuffix(bytes.TrimRight(data, " \t"), []byte("\n")),
+	}, nil
+}
+
+// save atomically replaces the project file, keeping its original file mode.
+func (p *projectFile) save() error {
+	var buf bytes.Buffer
+	if err := writeJSONValue(&buf, p.root, nil, p.indent, 0); err != nil {
+		return err
+	}
+
+	data := buf.Bytes()
+
+	if p.trailingNewline {
+		data = append(data, '\n')
+	}
+
+	if p.crlf {
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p.path), "."+projectFileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(p.mode); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), p.path)
+}
+
+// packageDirectory returns the package directory at index i, or nil.
+func (p *projectFile) packageDirectory(i int) *jsonObject {
+	dirs, _ := p.root.get("packageDirectories").([]interface{})
+	if i < 0 || i >= len(dirs) {
+		return nil
+	}
+
+	dir, _ := dirs[i].(*jsonObject)
+	return dir
+}
+
+// selectedPackageDirectory returns the package directory chosen with SetPackageDirectory, or the default directory.
+func (p *projectFile) selectedPackageDirectory() (*jsonObject, error) {
+	proj, err := readProject()
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := selectPackageDirectory(proj, packageDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := p.packageDirectory(i)
+	if dir == nil {
+		return nil, fmt.Errorf("Invalid %s: packageDirectories must be objects", projectFileName)
+	}
+
+	return dir, nil
+}
+
+// packageAliases returns the packageAliases object, adding it when missing.
+func (p *projectFile) packageAliases() *jsonObject {
+	aliases, ok := p.root.get("packageAliases").(*jsonObject)
+	if !ok {
+		aliases = newJSONObject()
+		p.root.set("packageAliases", aliases)
+	}
+
+	return aliases
+}
+
+// dependencies returns the package directory's dependencies.
+func dependencies(dir *jsonObject) []*jsonObject {
+	values, _ := dir.get("dependencies").([]interface{})
+
+	deps := make([]*jsonObject, 0, len(values))
+	for _, v := range values {
+		if dep, ok := v.(*jsonObject); ok {
+			deps = append(deps, dep)
+		}
+	}
+
+	return deps
+}
+
+// dependency returns the package directory's dependency on the package, or nil.
+func dependency(dir *jsonObject, pkg string) *jsonObject {
+	for _, dep := range dependencies(dir) {
+		if dep.getString("package") == pkg {
+			return dep
+		}
+	}
+
+	return nil
+}
+
+// addDependency appends a dependency on the package to the package directory, unless it already has one.
+func addDependency(dir *jsonObject, pkg string) *jsonObject {
+	if dep := dependency(dir, pkg); dep != nil {
+		return dep
+	}
+
+	dep := newJSONObject()
+	dep.set("package", pkg)
+
+	values, _ := dir.get("dependencies").([]interface{})
+	dir.set("dependencies", append(values, dep))
+
+	return dep
+}
+
+// removeDependencies removes the package directory's dependencies matching remove.
+func removeDependencies(dir *jsonObject, remove func(dep *jsonObject) bool) {
+	values, ok := dir.get("dependencies").([]interface{})
+	if !ok {
+		return
+	}
+
+	kept := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if dep, ok := v.(*jsonObject); ok && remove(dep) {
+			continue
+		}
+
+		kept = append(kept, v)
+	}
+
+	if len(kept) < len(values) {
+		dir.set("dependencies", kept)
+	}
+}
+
+// detectIndent returns the indentation of the first indented line, or an empty
+// string when the file has no indented lines.
+func detectIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n")[1:] {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimLeft(line, " \t")
+		if len(trimmed) > 0 && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+
+	if bytes.Count(bytes.TrimSpace(data), []byte("\n")) > 0 {
+		return "  "
+	}
+
+	return ""
+}
+
+// jsonObject is a JSON object that remembers the order of its keys and,
+// for objects read from a file, the text of the object and of its values.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+
+	source []byte
+	raw    map[string][]byte
+	edited bool
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: make(map[string]interface{}), raw: make(map[string][]byte)}
+}
+
+func (o *jsonObject) get(key string) interface{} {
+	return o.values[key]
+}
+
+func (o *jsonObject) getString(key string) string {
+	s, _ := o.values[key].(string)
+	return s
+}
+
+// set replaces the value of key, appending the key when it is new.
+func (o *jsonObject) set(key string, value interface{}) {
+	old, ok := o.values[key]
+	if !ok {
+		o.keys = append(o.keys, key)
+	}
+
+	if s, isString := value.(string); ok && isString && old == s {
+		return
+	}
+
+	o.values[key] = value
+	delete(o.raw, key)
+	o.edited = true
+}
+
+func (o *jsonObject) delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+
+	delete(o.values, key)
+	delete(o.raw, key)
+	o.edited = true
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// unchanged reports whether the value and everything in it is as it was read.
+func unchanged(value interface{}) bool {
+	switch v := value.(type) {
+	case *jsonObject:
+		if v.edited {
+			return false
+		}
+
+		for _, key := range v.keys {
+			if !unchanged(v.values[key]) {
+				return false
+			}
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if !unchanged(elem) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// writeJSONValue writes the value's original text when it is unchanged, otherwise
+// it writes objects and arrays one entry per line indented to the depth.
+func writeJSONValue(buf *bytes.Buffer, value interface{}, text []byte, indent string, depth int) error {
+	if text != nil && unchanged(value) {
+		buf.Write(text)
+		return nil
+	}
+
+	switch v := value.(type) {
+	case *jsonObject:
+		if len(v.keys) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+
+		buf.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONNewline(buf, indent, depth+1)
+
+			if err := writeJSONScalar(buf, key); err != nil {
+				return err
+			}
+
+			buf.WriteByte(':')
+			if len(indent) > 0 {
+				buf.WriteByte(' ')
+			}
+
+			if err := writeJSONValue(buf, v.values[key], v.raw[key], indent, depth+1); err != nil {
+				return err
+			}
+		}
+		writeJSONNewline(buf, indent, depth)
+		buf.WriteByte('}')
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONNewline(buf, indent, depth+1)
+
+			var text []byte
+			if obj, ok := elem.(*jsonObject); ok {
+				text = obj.source
+			}
+
+			if err := writeJSONValue(buf, elem, text, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		writeJSONNewline(buf, indent, depth)
+		buf.WriteByte(']')
+	default:
+		return writeJSONScalar(buf, v)
+	}
+
+	return nil
+}
+
+func writeJSONScalar(buf *bytes.Buffer, value interface{}) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(value); err != nil {
+		return err
+	}
+
+	// Encode ends every value with a newline
+	buf.Truncate(buf.Len() - 1)
+
+	return nil
+}
+
+func writeJSONNewline(buf *bytes.Buffer, indent string, depth int) {
+	if len(indent) == 0 {
+		return
+	}
+
+	buf.WriteByte('\n')
+	buf.WriteString(strings.Repeat(indent, depth))
+}
+
+// decodeJSONValue decodes the next value from data, keeping the key order of objects,
+// the exact text of numbers and the text of each object and of its values.
+func decodeJSONValue(dec *json.Decoder, data []byte) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		start := dec.InputOffset() - 1
+
+		obj := newJSONObject()
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			valueStart := dec.InputOffset()
+			value, err := decodeJSONValue(dec, data)
+			if err != nil {
+				return nil, err
+			}
+
+			key := keyTok.(string)
+			obj.set(key, value)
+			obj.raw[key] = valueText(data[valueStart:dec.InputOffset()])
+		}
+
+		if _, err = dec.Token(); err != nil {
+			return nil, err
+		}
+
+		obj.source = data[start:dec.InputOffset()]
+		obj.edited = false
+
+		return obj, nil
+	case '[':
+		values := []interface{}{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec, data)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		_, err = dec.Token()
+		return values, err
+	default:
+		return nil, fmt.Errorf("unexpected %s", delim)
+	}
+}
+
+// valueText trims the separator and whitespace read before an object's value.
+func valueText(text []byte) []byte {
+	text = bytes.TrimLeft(text, " \t\r\n")
+	text = bytes.TrimPrefix(text, []byte(":"))
+
+	return bytes.TrimLeft(text, " \t\r\n")
+}
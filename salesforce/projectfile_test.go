@@ -0,0 +1,91 @@
+package salesforce
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "Update the golden files in testdata")
+
+func TestProjectFileGolden(t *testing.T) {
+	const query = "data query --target-org user1@example.com --use-tooling-api --query "
+
+	tests := []struct {
+		name   string
+		remove bool
+		mode   os.FileMode
+	}{
+		// Unknown keys, key order, inline objects and arrays and number and string text are kept
+		{name: "unknown-keys", mode: 0600},
+		{name: "crlf-tabs", mode: 0644},
+		{name: "compact", mode: 0640},
+		{name: "remove", remove: true, mode: 0600},
+		// Aliases another package directory still references are kept
+		{name: "remove-shared", remove: true, mode: 0644},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useLockTestRunner(t, &stubRunner{outputs: map[string]string{
+				query + subscriberPkgVersionFields + " WHERE Id IN ('04t000000000001AAA')": `{"status":0,"result":{"done":true,"records":[
+					{"Id":"04t000000000001AAA","SubscriberPackageId":"033000000000001AAA","MajorVersion":1,"MinorVersion":0,"PatchVersion":0,"BuildNumber":1}]}}`,
+				query + "SELECT Id, Name FROM SubscriberPackage WHERE Id IN ('033000000000001AAA')": `{"status":0,"result":{"done":true,"records":[{"Id":"033000000000001AAA","Name":"Base"}]}}`,
+			}})
+
+			input, err := ioutil.ReadFile(filepath.Join("testdata", "projectfile", tt.name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := useTestProject(t, string(input))
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			if tt.remove {
+				err = removeDependencyFromProjectFile(ctx, "user1@example.com", "04t000000000001AAA")
+			} else {
+				err = upsertDependencyToProjectFile(ctx, "user1@example.com", "04t000000000001AAA")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "projectfile", tt.name+".golden.json")
+			if *updateGolden {
+				if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s =\n%s\nwant\n%s", projectFileName, got, want)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Mode().Perm() != tt.mode {
+				t.Errorf("mode = %v, want %v", info.Mode().Perm(), tt.mode)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package salesforce
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the most "did you mean" suggestions listed for an unknown package.
+const maxSuggestions = 3
+
+// findPackageID returns the 0Ho ID of the dev hub package referenced by a 0Ho or 033 ID, a
+// packageAliases key, a namespace: or namespace:Name reference, or its name or alias.
+// Names, aliases and namespaces are matched case-insensitively. An empty ID is
+// returned when the dev hub does not own the package.
+func findPackageID(ctx context.Context, ref string) (string, error) {
+	if strings.HasPrefix(ref, packagePrefix) {
+		return ref, nil
+	}
+
+	if alias, ok := projectAlias(ref); ok {
+		if strings.HasPrefix(alias, packagePrefix) {
+			return alias, nil
+		}
+
+		for _, ver := range pkgVersions {
+			if sameID(ver.ID, alias) {
+				return ver.PackageID, nil
+			}
+		}
+	}
+
+	isSubscriberID := strings.HasPrefix(ref, subscriberPrefix)
+	namespace, name, isNamespaced := splitNamespace(ref)
+
+	if !isSubscriberID && !isNamespaced {
+		for _, ver := range pkgVersions {
+			if strings.EqualFold(ver.Name, ref) {
+				return ver.PackageID, nil
+			}
+		}
+	}
+
+	if err := getPkgs(ctx); err != nil {
+		return "", err
+	}
+
+	for _, pkg := range pkgList {
+		switch {
+		case isSubscriberID:
+			if sameID(pkg.SubscriberPackageID, ref) {
+				return pkg.ID, nil
+			}
+		case isNamespaced:
+			if strings.EqualFold(pkg.NamespacePrefix, namespace) && (len(name) == 0 || strings.EqualFold(pkg.Name, name) || strings.EqualFold(pkg.Alias, name)) {
+				return pkg.ID, nil
+			}
+		default:
+			if strings.EqualFold(pkg.Name, ref) || strings.EqualFold(pkg.Alias, ref) {
+				return pkg.ID, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// packageNotFound returns the error for a package reference no source could resolve, including
+// why a source could not be searched and suggesting the known packages closest to it.
+func packageNotFound(ref string, sourceErr error) error {
+	err := fmt.Errorf("%w with alias: %s", ErrPackageNotFound, ref)
+	if sourceErr != nil {
+		err = fmt.Errorf("%w (%s)", err, strings.TrimSuffix(sourceErr.Error(), "."))
+	}
+
+	if suggestions := suggestPackages(ref); len(suggestions) > 0 {
+		err = fmt.Errorf("%w. Did you mean %s?", err, strings.Join(suggestions, ", "))
+	}
+
+	return err
+}
+
+// splitNamespace splits a namespace:Name package reference, the name may be empty.
+func splitNamespace(ref string) (string, string, bool) {
+	i := strings.Index(ref, ":")
+	if i < 0 {
+		return "", ref, false
+	}
+
+	return ref[:i], ref[i+1:], true
+}
+
+// projectAlias looks up a packageAliases key of the located project, exactly and then case-insensitively.
+func projectAlias(key string) (string, bool) {
+	if len(projectPath) == 0 {
+		return "", false
+	}
+
+	proj, err := readProject()
+	if err != nil {
+		return "", false
+	}
+
+	if alias, ok := proj.PackageAliases[key]; ok {
+		return alias, true
+	}
+
+	for k, alias := range proj.PackageAliases {
+		if strings.EqualFold(k, key) {
+			return alias, true
+		}
+	}
+
+	return "", false
+}
+
+// suggestPackages returns the known package names, aliases and namespaces closest to the unknown reference.
+func suggestPackages(ref string) []string {
+	var known []string
+	add := func(name string) {
+		if len(name) > 0 && !contains(known, name) {
+			known = append(known, name)
+		}
+	}
+
+	for _, ver := range pkgVersions {
+		add(ver.Name)
+	}
+
+	for _, pkg := range installedPkgs {
+		add(pkg.SubscriberPackageName)
+	}
+
+	for _, pkg := range pkgList {
+		add(pkg.Name)
+		add(pkg.Alias)
+		if len(pkg.NamespacePrefix) > 0 {
+			add(pkg.NamespacePrefix + ":" + pkg.Name)
+		}
+	}
+
+	if len(projectPath) > 0 {
+		if proj, err := readProject(); err == nil {
+			for key := range proj.PackageAliases {
+				add(key)
+			}
+		}
+	}
+
+	// Allow roughly one typo in every three characters
+	maxDistance := len(ref) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	distances := make(map[string]int)
+	var suggestions []string
+	for _, name := range known {
+		d := editDistance(strings.ToLower(ref), strings.ToLower(name))
+		if d <= maxDistance {
+			distances[name] = d
+			suggestions = append(suggestions, name)
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return distances[suggestions[i]] < distances[suggestions[j]]
+	})
+
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(t)]
+}
+
+// minInt returns the smallest of the values.
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,154 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// usePackageLists serves the dev hub's packages and versions from memory instead of the cli.
+func usePackageLists(t *testing.T) {
+	t.Helper()
+
+	pkgList = []Pkg{
+		{Name: "Core", ID: "0Ho000000000001AAA", SubscriberPackageID: "033000000000001AAA", NamespacePrefix: "acme", Alias: "CorePkg"},
+		{Name: "Billing", ID: "0Ho000000000002AAA", SubscriberPackageID: "033000000000002AAA", NamespacePrefix: "acme"},
+		{Name: "Reports", ID: "0Ho000000000003AAA", SubscriberPackageID: "033000000000003AAA", NamespacePrefix: "rpt"},
+	}
+	pkgVersions = []PkgVersion{
+		{Name: "Core", ID: "04t000000000001AAA", PackageID: "0Ho000000000001AAA", Version: "1.0.0.1", IsReleased: true},
+	}
+	installedPkgs = []InstalledPkg{{SubscriberPackageName: "Analytics"}}
+
+	t.Cleanup(func() {
+		pkgList, pkgVersions, installedPkgs = nil, nil, nil
+	})
+}
+
+func TestFindPackageID(t *testing.T) {
+	usePackageLists(t)
+	useTestProject(t, `{"packageDirectories": [{"path": "force-app"}], "packageAliases": {
+		"Billing": "0Ho000000000002AAA",
+		"Core@1.0.0-1": "04t000000000001",
+		"Partner": "04t000000000009AAA"
+	}}`)
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "0Ho000000000003AAA", want: "0Ho000000000003AAA"},
+		{ref: "033000000000002AAA", want: "0Ho000000000002AAA"},
+		{ref: "033000000000003", want: "0Ho000000000003AAA"},
+		{ref: "billing", want: "0Ho000000000002AAA"},
+		{ref: "core@1.0.0-1", want: "0Ho000000000001AAA"},
+		{ref: "CORE", want: "0Ho000000000001AAA"},
+		{ref: "corepkg", want: "0Ho000000000001AAA"},
+		{ref: "Reports", want: "0Ho000000000003AAA"},
+		{ref: "rpt:", want: "0Ho000000000003AAA"},
+		{ref: "ACME:billing", want: "0Ho000000000002AAA"},
+		{ref: "acme:CorePkg", want: "0Ho000000000001AAA"},
+		{ref: "rpt:Billing", want: ""},
+		{ref: "033000000000009AAA", want: ""},
+		{ref: "Partner", want: ""},
+		{ref: "Unknown", want: ""},
+	}
+
+	for _, tt := range tests {
+		got, err := findPackageID(context.Background(), tt.ref)
+		if err != nil {
+			t.Errorf("findPackageID(%s) error = %v", tt.ref, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("findPackageID(%s) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestSplitNamespace(t *testing.T) {
+	tests := []struct {
+		ref       string
+		namespace string
+		name      string
+		ok        bool
+	}{
+		{ref: "acme:Core", namespace: "acme", name: "Core", ok: true},
+		{ref: "acme:", namespace: "acme", name: "", ok: true},
+		{ref: "Core", namespace: "", name: "Core", ok: false},
+	}
+
+	for _, tt := range tests {
+		namespace, name, ok := splitNamespace(tt.ref)
+		if namespace != tt.namespace || name != tt.name || ok != tt.ok {
+			t.Errorf("splitNamespace(%s) = %q, %q, %t, want %q, %q, %t", tt.ref, namespace, name, ok, tt.namespace, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"core", "", 4},
+		{"core", "core", 0},
+		{"core", "cores", 1},
+		{"core", "cre", 1},
+		{"billing", "biling", 1},
+		{"kitten", "sitting", 3},
+		{"café", "cafe", 1},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestPackages(t *testing.T) {
+	usePackageLists(t)
+	useTestProject(t, `{"packageDirectories": [{"path": "force-app"}], "packageAliases": {"Partner": "04t000000000009AAA"}}`)
+
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		// Closest first, compared case-insensitively
+		{ref: "core", want: []string{"Core"}},
+		{ref: "corepk", want: []string{"CorePkg", "Core"}},
+		{ref: "Biling", want: []string{"Billing"}},
+		{ref: "acme:Biling", want: []string{"acme:Billing"}},
+		{ref: "Analytic", want: []string{"Analytics"}},
+		{ref: "partnr", want: []string{"Partner"}},
+		// At most 2 edits for short references, and one in every three characters for longer ones
+		{ref: "Cor", want: []string{"Core"}},
+		{ref: "Cx", want: nil},
+		{ref: "Rxxxxts", want: nil},
+		{ref: "Zzz", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := suggestPackages(tt.ref); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("suggestPackages(%s) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestPackageNotFound(t *testing.T) {
+	usePackageLists(t)
+
+	err := packageNotFound("Biling", ErrNoDefaultDevHub)
+	if !errors.Is(err, ErrPackageNotFound) {
+		t.Fatalf("packageNotFound() = %v, want ErrPackageNotFound", err)
+	}
+
+	if !strings.HasSuffix(err.Error(), "Did you mean Billing?") {
+		t.Errorf("packageNotFound() = %v, want a suggestion of Billing", err)
+	}
+}
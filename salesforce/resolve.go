@@ -0,0 +1,192 @@
+package salesforce
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxSoqlLength keeps batched queries well under the length
+// limit of a query sent in the url of a tooling api request.
+const maxSoqlLength = 10000
+
+const subscriberPkgVersionFields = "SELECT Id, SubscriberPackageId, MajorVersion, MinorVersion, PatchVersion, BuildNumber, Package2ContainerOptions, IsBeta, Dependencies FROM SubscriberPackageVersion"
+
+var subscriberPkgVersions = make(map[string]*SubscriberPkgVersion)
+
+// resolveDependencies fetches the SubscriberPkgVersion of each root and every package
+// they depend on, querying one level of the dependency tree at a time.
+func resolveDependencies(ctx context.Context, org string, roots []string) (map[string]*SubscriberPkgVersion, error) {
+	resolved := make(map[string]*SubscriberPkgVersion)
+
+	level := roots
+	for len(level) > 0 {
+		versions, err := getSubscriberPkgVersions(ctx, org, level)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range level {
+			resolved[id] = versions[id]
+		}
+
+		var next []string
+		for _, id := range level {
+			for _, dep := range versions[id].Dependencies.Ids {
+				depID := dep.SubscriberPackageVersionID
+				if _, ok := resolved[depID]; !ok && !contains(next, depID) {
+					next = append(next, depID)
+				}
+			}
+		}
+
+		level = next
+	}
+
+	return resolved, nil
+}
+
+// getSubscriberPkgVersions fetches the SubscriberPkgVersion records for ids in as few queries as
+// possible, with package names joined from their SubscriberPackage records. The versions are
+// returned keyed by the ids as given, in their 15 or 18 character form.
+func getSubscriberPkgVersions(ctx context.Context, org string, ids []string) (map[string]*SubscriberPkgVersion, error) {
+	versions := make(map[string]*SubscriberPkgVersion)
+
+	var missing []string
+	for _, id := range ids {
+		if pkv, ok := subscriberPkgVersions[shortID(id)]; ok {
+			versions[id] = pkv
+		} else if !containsID(missing, id) {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) == 0 {
+		return versions, nil
+	}
+
+	fetched := make(map[string]*SubscriberPkgVersion)
+	for _, soql := range batchQueries(subscriberPkgVersionFields+" WHERE Id IN ", missing) {
+		var records []SubscriberPkgVersion
+		if err := toolingRecords(ctx, org, soql, &records); err != nil {
+			return nil, err
+		}
+
+		for i := range records {
+			fetched[shortID(records[i].ID)] = &records[i]
+		}
+	}
+
+	var pkgIDs []string
+	for _, id := range missing {
+		pkv, ok := fetched[shortID(id)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, id)
+		}
+
+		if !containsID(pkgIDs, pkv.PackageID) {
+			pkgIDs = append(pkgIDs, pkv.PackageID)
+		}
+	}
+
+	pkgs, err := getSubscriberPkgs(ctx, org, pkgIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range missing {
+		pkv := fetched[shortID(id)]
+		pkv.Name = pkgs[shortID(pkv.PackageID)].Name
+
+		subscriberPkgVersions[shortID(id)] = pkv
+		versions[id] = pkv
+	}
+
+	return versions, nil
+}
+
+// getSubscriberPkgs fetches the SubscriberPkg records for ids in as few queries as possible,
+// keyed by the 15 character form of their ID.
+func getSubscriberPkgs(ctx context.Context, org string, ids []string) (map[string]*SubscriberPkg, error) {
+	pkgs := make(map[string]*SubscriberPkg)
+
+	for _, soql := range batchQueries("SELECT Id, Name FROM SubscriberPackage WHERE Id IN ", ids) {
+		var records []SubscriberPkg
+		if err := toolingRecords(ctx, org, soql, &records); err != nil {
+			return nil, err
+		}
+
+		for i := range records {
+			pkgs[shortID(records[i].ID)] = &records[i]
+		}
+	}
+
+	for _, id := range ids {
+		if _, ok := pkgs[shortID(id)]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, id)
+		}
+	}
+
+	return pkgs, nil
+}
+
+// batchQueries splits ids into as few `prefix ('id1','id2')` queries as
+// possible while keeping each query under maxSoqlLength.
+func batchQueries(prefix string, ids []string) []string {
+	var queries []string
+	var batch []string
+	length := len(prefix) + 2
+
+	for _, id := range ids {
+		quoted := "'" + id + "'"
+		if len(batch) > 0 && length+len(quoted)+1 > maxSoqlLength {
+			queries = append(queries, prefix+"("+strings.Join(batch, ",")+")")
+			batch = nil
+			length = len(prefix) + 2
+		}
+
+		batch = append(batch, quoted)
+		length += len(quoted) + 1
+	}
+
+	if len(batch) > 0 {
+		queries = append(queries, prefix+"("+strings.Join(batch, ",")+")")
+	}
+
+	return queries
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shortID returns the 15 character form of a Salesforce ID. The 18 character form only adds a
+// checksum of the case of the first 15, so both forms of an ID share the same short ID.
+func shortID(id string) string {
+	if len(id) == 18 {
+		return id[:15]
+	}
+
+	return id
+}
+
+// sameID reports whether two Salesforce IDs, in their 15 or 18 character form, name the same record.
+func sameID(a string, b string) bool {
+	return shortID(a) == shortID(b)
+}
+
+func containsID(list []string, id string) bool {
+	for _, v := range list {
+		if sameID(v, id) {
+			return true
+		}
+	}
+
+	return false
+}
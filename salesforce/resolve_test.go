@@ -0,0 +1,62 @@
+package salesforce
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetSubscriberPkgVersionsShortIDs(t *testing.T) {
+	const query = "data query --target-org user1@example.com --use-tooling-api --query "
+
+	SetRunner(&stubRunner{outputs: map[string]string{
+		"org list": `{"status":0,"result":{"nonScratchOrgs":[],"scratchOrgs":[]}}`,
+		query + subscriberPkgVersionFields + " WHERE Id IN ('04t000000000001')": `{"status":0,"result":{"done":true,"records":[
+			{"Id":"04t000000000001AAA","SubscriberPackageId":"033000000000001AAA","MajorVersion":1,"MinorVersion":2,"PatchVersion":0,"BuildNumber":1}]}}`,
+		query + "SELECT Id, Name FROM SubscriberPackage WHERE Id IN ('033000000000001AAA')": `{"status":0,"result":{"done":true,"records":[{"Id":"033000000000001AAA","Name":"Base"}]}}`,
+	}})
+	EnableAPI(false)
+	defer func() {
+		SetRunner(&ExecRunner{})
+		EnableAPI(true)
+	}()
+
+	ctx := context.Background()
+	versions, err := getSubscriberPkgVersions(ctx, "user1@example.com", []string{"04t000000000001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ver := versions["04t000000000001"]; ver == nil || ver.Name != "Base" {
+		t.Fatalf("versions[04t000000000001] = %+v, want Base", ver)
+	}
+
+	// The 18 character ID is served from the cache
+	ver, err := getSubscriberPkgVersion(ctx, "user1@example.com", "04t000000000001AAA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ver == nil || ver.Name != "Base" {
+		t.Errorf("getSubscriberPkgVersion(04t000000000001AAA) = %+v, want Base", ver)
+	}
+}
+
+func TestSameID(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"04t000000000001AAA", "04t000000000001AAA", true},
+		{"04t000000000001", "04t000000000001AAA", true},
+		{"04t000000000001AAA", "04t000000000001", true},
+		{"04t000000000001AAA", "04t000000000002AAA", false},
+		{"04t00000000000a", "04t00000000000A", false},
+		{"Base", "Base", true},
+	}
+
+	for _, tt := range tests {
+		if got := sameID(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameID(%s, %s) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
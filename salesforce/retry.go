@@ -0,0 +1,103 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// RetryClasses maps the names used in configuration to the transient errors that can be retried.
+var RetryClasses = map[string]error{
+	"unavailable": ErrVersionUnavailable,
+	"lock":        ErrRowLock,
+	"concurrent":  ErrConcurrentInstall,
+}
+
+// RetryPolicy controls how failed installs, uninstalls and queries are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, 1 disables retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled after each retry up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter randomizes each delay by up to this fraction of it, between 0 and 1.
+	Jitter float64
+	// Retryable lists the errors which are retried, see RetryClasses.
+	Retryable []error
+}
+
+var retryPolicy = RetryPolicy{MaxAttempts: 1}
+
+// SetRetryPolicy sets the retry policy used by the salesforce package.
+func SetRetryPolicy(p RetryPolicy) {
+	retryPolicy = p
+}
+
+// ParseRetryClasses converts class names from configuration to their errors.
+func ParseRetryClasses(names []string) ([]error, error) {
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		err, ok := RetryClasses[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown retry class: %s", name)
+		}
+
+		errs = append(errs, err)
+	}
+
+	return errs, nil
+}
+
+// retryable reports whether err is one of the policy's retryable errors.
+func (p *RetryPolicy) retryable(err error) bool {
+	for _, target := range p.Retryable {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// delay returns the jittered backoff before the given retry, counting from 1.
+func (p *RetryPolicy) delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+
+	return d
+}
+
+// retry runs fn until it succeeds, fails with an error the policy does not retry,
+// or the policy runs out of attempts. Each retry is logged with the operation name.
+func retry(ctx context.Context, operation string, fn func() error) error {
+	p := retryPolicy
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !p.retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := p.delay(attempt)
+		fmt.Fprintf(out, "%s failed: %v\nRetrying in %s (attempt %d of %d)\n", operation, err, wait.Round(time.Millisecond), attempt+1, p.MaxAttempts)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	SetOutput(ioutil.Discard)
+	defer func() {
+		SetOutput(os.Stdout)
+		SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	}()
+
+	retryable := []error{ErrRowLock, ErrConcurrentInstall}
+	otherErr := errors.New("INVALID_TYPE: sObject type does not exist")
+
+	tests := []struct {
+		name     string
+		attempts int
+		failures int
+		err      error
+		calls    int
+		want     error
+	}{
+		{name: "succeeds first time", attempts: 3, calls: 1},
+		{name: "succeeds after retries", attempts: 3, failures: 2, err: ErrRowLock, calls: 3},
+		{name: "runs out of attempts", attempts: 3, failures: 5, err: ErrConcurrentInstall, calls: 3, want: ErrConcurrentInstall},
+		{name: "not retryable", attempts: 3, failures: 5, err: otherErr, calls: 1, want: otherErr},
+		{name: "retries disabled", attempts: 1, failures: 5, err: ErrRowLock, calls: 1, want: ErrRowLock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetRetryPolicy(RetryPolicy{MaxAttempts: tt.attempts, Retryable: retryable})
+
+			calls := 0
+			err := retry(context.Background(), "Install", func() error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+
+				return nil
+			})
+
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("retry() error = %v, want %v", err, tt.want)
+			}
+
+			if calls != tt.calls {
+				t.Errorf("retry() called fn %d times, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenCancelled(t *testing.T) {
+	SetOutput(ioutil.Discard)
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 5, Backoff: time.Hour, Retryable: []error{ErrRowLock}})
+	defer func() {
+		SetOutput(os.Stdout)
+		SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	err := retry(ctx, "Install", func() error {
+		calls++
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return ErrRowLock
+	})
+
+	if !errors.Is(err, ErrRowLock) || calls != 1 {
+		t.Errorf("retry() = %v after %d calls, want ErrRowLock after 1", err, calls)
+	}
+
+	// A cancelled context is not retried at all
+	calls = 0
+	err = retry(ctx, "Install", func() error {
+		calls++
+		return ErrRowLock
+	})
+
+	if !errors.Is(err, ErrRowLock) || calls != 1 {
+		t.Errorf("retry() = %v after %d calls, want ErrRowLock after 1", err, calls)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		policy RetryPolicy
+		retry  int
+		want   time.Duration
+	}{
+		{policy: RetryPolicy{Backoff: time.Second}, retry: 1, want: time.Second},
+		{policy: RetryPolicy{Backoff: time.Second}, retry: 2, want: 2 * time.Second},
+		{policy: RetryPolicy{Backoff: time.Second}, retry: 4, want: 8 * time.Second},
+		{policy: RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}, retry: 3, want: 4 * time.Second},
+		{policy: RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}, retry: 4, want: 5 * time.Second},
+		{policy: RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}, retry: 60, want: 5 * time.Second},
+		{policy: RetryPolicy{}, retry: 3, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.delay(tt.retry); got != tt.want {
+			t.Errorf("%+v.delay(%d) = %s, want %s", tt.policy, tt.retry, got, tt.want)
+		}
+	}
+
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 4 * time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if d := p.delay(1); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("delay(1) = %s with 50%% jitter, want between 500ms and 1.5s", d)
+		}
+
+		if d := p.delay(5); d < 2*time.Second || d > 6*time.Second {
+			t.Fatalf("delay(5) = %s with 50%% jitter, want between 2s and 6s", d)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package salesforce
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Runner executes Salesforce CLI commands on behalf of the salesforce package.
+type Runner interface {
+	// CLI returns the Salesforce CLI executable the runner targets, sf or sfdx.
+	CLI() (string, error)
+	// Run executes the command with its output written to os.Stdout.
+	Run(ctx context.Context, arg ...string) error
+	// RunJSON executes the command with --json and returns its output.
+	// The output is returned alongside any error so the cli's JSON
+	// error envelope can be decoded.
+	RunJSON(ctx context.Context, arg ...string) ([]byte, error)
+}
+
+var runner Runner = &ExecRunner{}
+
+// SetRunner replaces the Runner used by the salesforce package and
+// clears any results cached from the previous runner.
+func SetRunner(r Runner) {
+	runner = r
+
+	orgs = nil
+	scrOrgs = nil
+	pkgList = nil
+	pkgVersions = nil
+	installedPkgs = nil
+	subscriberPkgVersions = make(map[string]*SubscriberPkgVersion)
+}
+
+// ExecRunner runs commands with a Salesforce CLI executable found on PATH.
+type ExecRunner struct {
+	// Executable is the CLI to run, sf or sfdx. When empty the
+	// CLI is detected on PATH, preferring sf over sfdx.
+	Executable string
+}
+
+// CLI searches the directories named by the PATH environment
+// variable for the sf cli, falling back to the legacy sfdx cli.
+func (r *ExecRunner) CLI() (string, error) {
+	if len(r.Executable) > 0 {
+		return r.Executable, nil
+	}
+
+	for _, name := range []string{cliSf, cliSfdx} {
+		if _, err := exec.LookPath(name); err == nil {
+			r.Executable = name
+			return name, nil
+		}
+	}
+
+	return "", errors.New("Salesforce CLI (sf or sfdx) not found on %PATH%")
+}
+
+// Run runs the cli command with os.Stdout, returning its error output with any failure
+func (r *ExecRunner) Run(ctx context.Context, arg ...string) error {
+	cli, err := r.CLI()
+	if err != nil {
+		return err
+	}
+
+	sfdx := exec.CommandContext(ctx, cli, arg...)
+	stderr := new(bytes.Buffer)
+	sfdx.Stdout = os.Stdout
+	sfdx.Stderr = io.MultiWriter(os.Stderr, stderr)
+
+	// Keep the cli's error output with the error so it can be decoded
+	err = sfdx.Run()
+	if msg := strings.TrimSpace(stderr.String()); err != nil && len(msg) > 0 {
+		return fmt.Errorf("%w\n%s", err, msg)
+	}
+
+	return err
+}
+
+// RunJSON runs the cli command with JSON output
+func (r *ExecRunner) RunJSON(ctx context.Context, arg ...string) ([]byte, error) {
+	cli, err := r.CLI()
+	if err != nil {
+		return nil, err
+	}
+
+	arg = append(arg, "--json")
+
+	sfdx := exec.CommandContext(ctx, cli, arg...)
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	sfdx.Stdout = stdout
+	sfdx.Stderr = stderr
+
+	err = sfdx.Run()
+
+	// sfdx writes its JSON error envelope to stderr, sf writes it to stdout
+	if err != nil && !json.Valid(bytes.TrimSpace(stdout.Bytes())) {
+		return stderr.Bytes(), err
+	}
+
+	return stdout.Bytes(), err
+}
+
+// Fixture is a single recorded CLI invocation.
+type Fixture struct {
+	CLI    string          `json:"cli"`
+	Args   []string        `json:"args"`
+	JSON   bool            `json:"json"`
+	Output json.RawMessage `json:"output,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// RecordingRunner passes commands through to Runner and saves each
+// invocation as a Fixture in Dir. Recordings are meant to be shared in
+// bug reports, so access tokens are dropped and instance URLs and
+// usernames are replaced with placeholders before fixtures are written.
+type RecordingRunner struct {
+	Runner Runner
+	Dir    string
+
+	mu        sync.Mutex
+	count     int
+	usernames map[string]string
+}
+
+// redactedKeys are the fields of CLI output dropped from fixtures, as they
+// would let anyone holding the fixture act as the user in the org.
+var redactedKeys = map[string]bool{
+	"accesstoken":  true,
+	"refreshtoken": true,
+	"password":     true,
+	"clientsecret": true,
+}
+
+// redactedURL replaces the instance and login URLs in fixtures.
+const redactedURL = "https://example.my.salesforce.com"
+
+// targetOrgFlags are the flags whose value names the org a command runs against.
+var targetOrgFlags = map[string]bool{
+	"-u":               true,
+	"--targetusername": true,
+	"-o":               true,
+	"--target-org":     true,
+}
+
+// NewRecordingRunner returns a RecordingRunner that records the commands run by r into dir.
+func NewRecordingRunner(r Runner, dir string) (*RecordingRunner, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &RecordingRunner{Runner: r, Dir: dir, usernames: make(map[string]string)}, nil
+}
+
+// CLI returns the CLI targeted by the underlying runner.
+func (r *RecordingRunner) CLI() (string, error) {
+	return r.Runner.CLI()
+}
+
+// Run runs the command and records its args and result.
+func (r *RecordingRunner) Run(ctx context.Context, arg ...string) error {
+	err := r.Runner.Run(ctx, arg...)
+
+	if recErr := r.record(Fixture{Args: arg, Error: errorString(err)}); recErr != nil {
+		return recErr
+	}
+
+	return err
+}
+
+// RunJSON runs the command and records its args, JSON output and result.
+func (r *RecordingRunner) RunJSON(ctx context.Context, arg ...string) ([]byte, error) {
+	out, err := r.Runner.RunJSON(ctx, arg...)
+
+	fixture := Fixture{Args: arg, JSON: true, Error: errorString(err)}
+	if json.Valid(out) {
+		fixture.Output = out
+	}
+
+	if recErr := r.record(fixture); recErr != nil {
+		return nil, recErr
+	}
+
+	return out, err
+}
+
+func (r *RecordingRunner) record(fixture Fixture) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.count++
+
+	cli, err := r.Runner.CLI()
+	if err != nil {
+		return err
+	}
+	fixture.CLI = cli
+
+	if err := r.redact(&fixture); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%04d-%s.json", r.count, fixtureKey(fixture.JSON, fixture.Args))
+	return ioutil.WriteFile(filepath.Join(r.Dir, name), data, 0644)
+}
+
+// redact removes access tokens from the fixture and replaces instance URLs and usernames with
+// placeholders. Each username is always replaced by the same placeholder, so replayed commands
+// still target the org the recorded org list resolves an alias to.
+func (r *RecordingRunner) redact(fixture *Fixture) error {
+	if r.usernames == nil {
+		r.usernames = make(map[string]string)
+	}
+
+	for i := 1; i < len(fixture.Args); i++ {
+		if targetOrgFlags[fixture.Args[i-1]] && strings.Contains(fixture.Args[i], "@") {
+			r.username(fixture.Args[i])
+		}
+	}
+
+	if len(fixture.Output) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(fixture.Output))
+		dec.UseNumber()
+
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(r.redactValue(v))
+		if err != nil {
+			return err
+		}
+
+		fixture.Output = []byte(r.replaceUsernames(string(data)))
+	}
+
+	args := make([]string, len(fixture.Args))
+	for i, arg := range fixture.Args {
+		args[i] = r.replaceUsernames(arg)
+	}
+	fixture.Args = args
+	fixture.Error = r.replaceUsernames(fixture.Error)
+
+	return nil
+}
+
+// redactValue drops secret fields and replaces URLs in decoded JSON, collecting the usernames it contains.
+func (r *RecordingRunner) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		// Visit keys in order so usernames are numbered the same way on every recording
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value := v[key]
+			lower := strings.ToLower(key)
+			s, isString := value.(string)
+
+			switch {
+			case redactedKeys[lower]:
+				delete(v, key)
+			case isString && strings.HasSuffix(lower, "url") && strings.HasPrefix(s, "http"):
+				v[key] = redactedURL
+			case isString && lower == "username":
+				r.username(s)
+			default:
+				v[key] = r.redactValue(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.redactValue(value)
+		}
+	}
+
+	return v
+}
+
+// username returns the placeholder replacing the username in fixtures.
+func (r *RecordingRunner) username(name string) string {
+	if placeholder, ok := r.usernames[name]; ok {
+		return placeholder
+	}
+
+	placeholder := fmt.Sprintf("user%d@example.com", len(r.usernames)+1)
+	r.usernames[name] = placeholder
+
+	return placeholder
+}
+
+func (r *RecordingRunner) replaceUsernames(s string) string {
+	// Replace longer usernames first in case one contains another
+	names := make([]string, 0, len(r.usernames))
+	for name := range r.usernames {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, name := range names {
+		s = strings.ReplaceAll(s, name, r.usernames[name])
+	}
+
+	return s
+}
+
+// ReplayRunner serves commands from fixtures saved by a RecordingRunner.
+// Fixtures with matching args are served in the order they were recorded.
+type ReplayRunner struct {
+	mu       sync.Mutex
+	cli      string
+	fixtures map[string][]Fixture
+}
+
+// NewReplayRunner loads the fixtures saved in dir.
+func NewReplayRunner(dir string) (*ReplayRunner, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	r := &ReplayRunner{cli: cliSfdx, fixtures: make(map[string][]Fixture)}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var fixture Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("Failed to read fixture %s: %v", file, err)
+		}
+
+		if len(fixture.CLI) > 0 {
+			r.cli = fixture.CLI
+		}
+
+		key := fixtureKey(fixture.JSON, fixture.Args)
+		r.fixtures[key] = append(r.fixtures[key], fixture)
+	}
+
+	return r, nil
+}
+
+// CLI returns the CLI the fixtures were recorded with. Replayed
+// commands never need the CLI to be installed.
+func (r *ReplayRunner) CLI() (string, error) {
+	return r.cli, nil
+}
+
+// Run serves the next recorded result for the command.
+func (r *ReplayRunner) Run(ctx context.Context, arg ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fixture, err := r.next(false, arg)
+	if err != nil {
+		return err
+	}
+
+	return fixtureError(fixture)
+}
+
+// RunJSON serves the next recorded output and result for the command.
+func (r *ReplayRunner) RunJSON(ctx context.Context, arg ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fixture, err := r.next(true, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fixture.Output), fixtureError(fixture)
+}
+
+func (r *ReplayRunner) next(isJSON bool, arg []string) (*Fixture, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fixtureKey(isJSON, arg)
+	queue := r.fixtures[key]
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("No recorded fixture for: %s %s", r.cli, strings.Join(arg, " "))
+	}
+
+	fixture := queue[0]
+	r.fixtures[key] = queue[1:]
+
+	return &fixture, nil
+}
+
+func fixtureKey(isJSON bool, arg []string) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%t\x00%s", isJSON, strings.Join(arg, "\x00"))
+
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+func fixtureError(fixture *Fixture) error {
+	if fixture.Error == "" {
+		return nil
+	}
+
+	return errors.New(fixture.Error)
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,276 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// replay points the salesforce package at the fixtures of the scenario in testdata/replay and at a
+// temporary copy of the scenario's project, returning the path of the copied sfdx-project.json.
+func replay(t *testing.T, scenario string) string {
+	t.Helper()
+
+	dir := filepath.Join("testdata", "replay", scenario)
+	r, err := NewReplayRunner(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetRunner(r)
+	SetOutput(ioutil.Discard)
+	SetPollInterval(time.Millisecond)
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	SetLockfileMode(LockfileUse)
+	SetPackageDirectory("")
+	SetPinDependencies(false)
+	EnableAPI(false)
+
+	project := t.TempDir()
+	files, err := filepath.Glob(filepath.Join(dir, "project", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(project, filepath.Base(file)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	projectPath = filepath.Join(project, projectFileName)
+	t.Cleanup(func() {
+		projectPath = ""
+		SetRunner(&ExecRunner{})
+		SetOutput(os.Stdout)
+		EnableAPI(true)
+	})
+
+	return projectPath
+}
+
+// readTestProject decodes the sfdx-project.json written by a test.
+func readTestProject(t *testing.T, path string) *SfdxProject {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var proj SfdxProject
+	if err := json.Unmarshal(data, &proj); err != nil {
+		t.Fatal(err)
+	}
+
+	return &proj
+}
+
+func TestReplayDevHub(t *testing.T) {
+	replay(t, "devhub")
+
+	org, err := DevHub(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if org.Alias != "hub" || org.UserName != "user1@example.com" {
+		t.Errorf("DevHub() = %s (%s), want hub (user1@example.com)", org.Alias, org.UserName)
+	}
+
+	for alias, want := range map[string]string{"hub": "user1@example.com", "scratch": "user3@example.com", "00D000000000002AAA": "user2@example.com"} {
+		got, err := getOrgUserID(context.Background(), alias)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("getOrgUserID(%s) = %s, want %s", alias, got, want)
+		}
+	}
+}
+
+func TestReplayInstallPackage(t *testing.T) {
+	path := replay(t, "install")
+
+	if err := InstallPackage(context.Background(), "scratch", "04t000000000002AAA"); err != nil {
+		t.Fatal(err)
+	}
+
+	proj := readTestProject(t, path)
+
+	var deps []string
+	for _, dep := range proj.PackageDirectories[0].Dependencies {
+		deps = append(deps, dep.PackageName)
+	}
+
+	if got, want := strings.Join(deps, ","), "Base,Extension"; got != want {
+		t.Errorf("dependencies = %s, want %s", got, want)
+	}
+
+	want := map[string]string{"Base": "04t000000000001AAA", "Extension": "04t000000000002AAA"}
+	for alias, id := range want {
+		if proj.PackageAliases[alias] != id {
+			t.Errorf("packageAliases[%s] = %s, want %s", alias, proj.PackageAliases[alias], id)
+		}
+	}
+}
+
+func TestReplayInstallPackageFailure(t *testing.T) {
+	replay(t, "install-failure")
+
+	err := InstallPackage(context.Background(), "scratch", "04t000000000001AAA")
+
+	var reqErr *InstallRequestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("InstallPackage() error = %v, want an InstallRequestError", err)
+	}
+
+	if reqErr.RequestID != "0Hf000000000001AAA" {
+		t.Errorf("RequestID = %s, want 0Hf000000000001AAA", reqErr.RequestID)
+	}
+}
+
+func TestReplayUninstallPackage(t *testing.T) {
+	path := replay(t, "uninstall")
+
+	if err := UninstallPackage(context.Background(), "scratch", "04t000000000001AAA"); err != nil {
+		t.Fatal(err)
+	}
+
+	proj := readTestProject(t, path)
+	if deps := proj.PackageDirectories[0].Dependencies; len(deps) != 0 {
+		t.Errorf("dependencies = %v, want none", deps)
+	}
+
+	if _, ok := proj.PackageAliases["Base"]; ok {
+		t.Errorf("packageAliases still contains Base")
+	}
+}
+
+func TestReplayUninstallPackageFailure(t *testing.T) {
+	path := replay(t, "uninstall-failure")
+
+	before, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UninstallPackage(context.Background(), "scratch", "04t000000000001AAA"); err == nil {
+		t.Fatal("UninstallPackage() succeeded, want the failed uninstall request")
+	}
+
+	after, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(before) != string(after) {
+		t.Errorf("sfdx-project.json was edited although the uninstall failed")
+	}
+}
+
+// stubRunner serves canned output for each command, keyed by its args.
+type stubRunner struct {
+	outputs map[string]string
+}
+
+func (r *stubRunner) CLI() (string, error) {
+	return cliSf, nil
+}
+
+func (r *stubRunner) Run(ctx context.Context, arg ...string) error {
+	return nil
+}
+
+func (r *stubRunner) RunJSON(ctx context.Context, arg ...string) ([]byte, error) {
+	out, ok := r.outputs[strings.Join(arg, " ")]
+	if !ok {
+		return nil, errors.New("unexpected command: " + strings.Join(arg, " "))
+	}
+
+	return []byte(out), nil
+}
+
+func TestRecordingRunnerRedacts(t *testing.T) {
+	stub := &stubRunner{outputs: map[string]string{
+		"org list": `{"status":0,"result":{"nonScratchOrgs":[{"username":"admin@acme.com","accessToken":"00D!secret","instanceUrl":"https://acme.my.salesforce.com","loginUrl":"https://login.salesforce.com"}],"scratchOrgs":[{"username":"test-abc@acme.com","accessToken":"00D!scratch","instanceUrl":"https://acme-dev.my.salesforce.com"}]}}`,
+		"package installed list --target-org admin@acme.com": `{"status":0,"result":[],"warnings":["Org admin@acme.com is a production org"]}`,
+	}}
+
+	dir := t.TempDir()
+	r, err := NewRecordingRunner(stub, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	out, err := r.RunJSON(ctx, "org", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(out), "00D!secret") {
+		t.Errorf("RunJSON() output was redacted, want the CLI output unchanged")
+	}
+
+	if _, err := r.RunJSON(ctx, "package", "installed", "list", "--target-org", "admin@acme.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var recorded strings.Builder
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorded.Write(data)
+	}
+
+	for _, secret := range []string{"00D!secret", "00D!scratch", "accessToken", "acme.my.salesforce.com", "login.salesforce.com", "admin@acme.com", "test-abc@acme.com"} {
+		if strings.Contains(recorded.String(), secret) {
+			t.Errorf("fixtures contain %q", secret)
+		}
+	}
+
+	// The fixtures still replay with the placeholder usernames
+	replayer, err := NewReplayRunner(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetRunner(replayer)
+	defer SetRunner(&ExecRunner{})
+
+	if err := getOrgs(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := replayer.RunJSON(ctx, "package", "installed", "list", "--target-org", "user1@example.com"); err != nil {
+		t.Errorf("replaying the redacted command: %v", err)
+	}
+
+	if len(orgs) != 1 || orgs[0].UserName != "user1@example.com" || orgs[0].AccessToken != "" || orgs[0].InstanceURL != redactedURL {
+		t.Errorf("replayed org list = %+v, want user1@example.com without an access token", orgs)
+	}
+
+	if len(scrOrgs) != 1 || scrOrgs[0].UserName != "user2@example.com" {
+		t.Errorf("replayed scratch orgs = %+v, want user2@example.com", scrOrgs)
+	}
+}
@@ -2,12 +2,13 @@ package salesforce
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -16,6 +17,7 @@ const (
 	defaultMarker      = "(D)"
 	packagePrefix      = "0Ho"
 	versionPrefix      = "04t"
+	subscriberPrefix   = "033"
 	orgPrefix          = "00D"
 	projectFileName    = "sfdx-project.json"
 	managedPackageType = "Managed"
@@ -24,41 +26,48 @@ const (
 var orgs []Org
 var scrOrgs []ScratchOrg
 var projectPath string
+var pkgList []Pkg
 var pkgVersions []PkgVersion
 var installedPkgs []InstalledPkg
 
-// CheckCli searches for the sfdx cli in the
-// directories named by the PATH environment variable.
+// out receives the progress messages printed while running commands.
+var out io.Writer = os.Stdout
+
+// SetOutput sets where progress messages are printed, such as os.Stderr when stdout is reserved for JSON.
+func SetOutput(w io.Writer) {
+	out = w
+}
+
+// CheckCli verifies a Salesforce CLI, sf or sfdx, is available to the current Runner.
 func CheckCli() error {
+	_, err := runner.CLI()
+	return err
+}
 
-	_, err := exec.LookPath("sfdx")
+// CliVersion prints the version of the Salesforce CLI in use.
+func CliVersion(ctx context.Context) error {
+	cmds, err := commands()
 	if err != nil {
-		return errors.New("SFDX CLI not found on %PATH%")
+		return err
 	}
 
-	return nil
+	return sfdx(ctx, cmds.versionArgs()...)
 }
 
 // DevHub searches your sfdx orgs for the org marked
 // as your default DevHub org.
-func DevHub() (*Org, error) {
-	if err := getOrgs(); err != nil {
+func DevHub(ctx context.Context) (*Org, error) {
+	if err := getOrgs(ctx); err != nil {
 		return nil, err
 	}
 
-	var devHub Org
 	for _, org := range orgs {
 		if org.IsDevHub && org.DefaultMarker == defaultMarker {
-			devHub = org
-			break
+			return &org, nil
 		}
 	}
 
-	if &devHub == nil {
-		return nil, errors.New("No default dev hub org found")
-	}
-
-	return &devHub, nil
+	return nil, ErrNoDefaultDevHub
 }
 
 // CheckSFDX searches the current directory and parent directories
@@ -68,7 +77,7 @@ func CheckSFDX() error {
 		return nil
 	}
 
-	fmt.Println("Locating SFDX Project File...")
+	fmt.Fprintln(out, "Locating SFDX Project File...")
 	wd, err := os.Getwd()
 	if err != nil {
 		panic(err)
@@ -79,12 +88,12 @@ func CheckSFDX() error {
 		return err
 	}
 
-	fmt.Println("Project File Found: " + projectPath)
+	fmt.Fprintln(out, "Project File Found: "+projectPath)
 	return nil
 }
 
 //InstallPackage installs the specified package to the specified org and updates dependencies in the project file
-func InstallPackage(org string, pkg string) error {
+func InstallPackage(ctx context.Context, org string, pkg string) error {
 	if err := CheckCli(); err != nil {
 		return err
 	}
@@ -93,64 +102,222 @@ func InstallPackage(org string, pkg string) error {
 		return err
 	}
 
-	org, err := getOrgUserID(org)
+	org, err := getOrgUserID(ctx, org)
 	if err != nil {
 		return err
 	}
 
-	if !strings.HasPrefix(pkg, versionPrefix) {
-		pkg, err = getPkgVersionID(pkg)
+	graph, err := lockedGraph(ctx, org, []string{pkg}, true)
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
-			return err
+	order, err := graph.InstallOrder()
+	if err != nil {
+		return err
+	}
+
+	return installNodes(ctx, org, order, true)
+}
+
+//InstallDependencies finds the required dependencies and installs them prior to the target package
+func InstallDependencies(ctx context.Context, org string, pkg string) error {
+	if err := CheckCli(); err != nil {
+		return err
+	}
+
+	if err := CheckSFDX(); err != nil {
+		return err
+	}
+
+	org, err := getOrgUserID(ctx, org)
+	if err != nil {
+		return err
+	}
+
+	graph, err := lockedGraph(ctx, org, []string{pkg}, true)
+	if err != nil {
+		return err
+	}
+
+	order, err := graph.InstallOrder()
+	if err != nil {
+		return err
+	}
+
+	var deps []*Node
+	for _, n := range order {
+		if n != graph.Roots[0] {
+			deps = append(deps, n)
 		}
 	}
 
-	err = InstallDependencies(org, pkg)
+	return installNodes(ctx, org, deps, true)
+}
+
+//InstallProjectDependencies installs the dependencies declared by the project's package directories, or only
+//by the package directory matched by path or package name when dir is set, as one combined dependency graph
+func InstallProjectDependencies(ctx context.Context, org string, dir string) error {
+	if err := CheckCli(); err != nil {
+		return err
+	}
+
+	if err := CheckSFDX(); err != nil {
+		return err
+	}
+
+	org, err := getOrgUserID(ctx, org)
 	if err != nil {
 		return err
 	}
 
-	installed := isPkgInstalled(org, pkg)
+	proj, err := readProject()
+	if err != nil {
+		return err
+	}
 
-	if !installed {
-		err = sfdx("force:package:install", "--package", pkg, "-u", org, "-w 100")
+	if len(proj.PackageDirectories) == 0 {
+		return errors.New("The project does not contain any packageDirectories")
+	}
+
+	refs := projectDependencyRefs(proj)
+	if len(dir) > 0 {
+		i, err := selectPackageDirectory(proj, dir)
 		if err != nil {
 			return err
 		}
+
+		refs = directoryDependencyRefs(proj, proj.PackageDirectories[i])
+	}
+
+	if len(refs) == 0 {
+		fmt.Fprintln(out, "The project does not declare any package dependencies")
+		return nil
 	}
 
-	err = upsertDependencyToProjectFile(org, pkg)
+	fmt.Fprintf(out, "Resolving %d project dependencies\n", len(refs))
+
+	graph, err := lockedGraph(ctx, org, refs, true)
+	if err != nil {
+		return err
+	}
+
+	order, err := graph.InstallOrder()
 	if err != nil {
 		return err
 	}
 
+	// The dependencies are already declared by the project file
+	return installNodes(ctx, org, order, false)
+}
+
+//installNodes installs each package version in order, skipping those already installed, and saves
+//them as dependencies in the project file when save is set
+func installNodes(ctx context.Context, org string, nodes []*Node, save bool) error {
+	fmt.Fprintln(out, "Install order:")
+	for i, n := range nodes {
+		progress.setLabel(n.ID, fmt.Sprintf("%s - %s", n, n.ID))
+		progress.addPending(n.ID)
+
+		fmt.Fprintf(out, "  %d. %s - %s\n", i+1, n, n.ID)
+	}
+
+	var installed, skipped []*Node
+	for _, n := range nodes {
+		done, err := installNode(ctx, org, n, save)
+		if err != nil {
+			return err
+		}
+
+		if done {
+			installed = append(installed, n)
+		} else {
+			skipped = append(skipped, n)
+		}
+	}
+
+	printInstallSummary(installed, skipped)
 	return nil
 }
 
-//InstallDependencies finds the required dependencies and installs them prior to the target package
-func InstallDependencies(org string, pkg string) error {
+//printInstallSummary lists the package versions installed and those skipped as already installed
+func printInstallSummary(installed []*Node, skipped []*Node) {
+	fmt.Fprintf(out, "Installed %d packages, %d already installed\n", len(installed), len(skipped))
+	for _, n := range installed {
+		fmt.Fprintf(out, "  + %s - %s\n", n, n.ID)
+	}
+
+	for _, n := range skipped {
+		fmt.Fprintf(out, "  = %s - %s\n", n, n.ID)
+	}
+}
+
+//isNodeInstalled reports whether the node's package version, or a later version of the same package, is installed in the org
+func isNodeInstalled(ctx context.Context, org string, n *Node) (bool, error) {
+	if n.Pkg == nil {
+		return false, nil
+	}
 
-	mainPkg, err := getSubscriberPkgVersion(org, pkg)
+	current, err := installedPackage(ctx, org, n.Pkg.PackageID)
+	if err != nil || current == nil {
+		return false, err
+	}
+
+	if sameID(current.SubscriberPackageVersionID, n.ID) {
+		fmt.Fprintf(out, "Package already installed: %s - %s\n", n, n.ID)
+		return true, nil
+	}
+
+	currentNum, err := current.VersionNumber()
 	if err != nil {
-		return err
+		return false, nil
 	}
 
-	fmt.Println(fmt.Sprintf("Installing Dependencies for package: %s - %s", mainPkg.Name, mainPkg.ID))
-	for _, dep := range mainPkg.Dependencies.Ids {
+	if n.Pkg.VersionNumber().Less(currentNum) {
+		fmt.Fprintf(out, "Package %s already installed at later version %s, skipping %s\n", n.Name, currentNum, n.Version)
+		return true, nil
+	}
 
-		err = InstallPackage(org, dep.SubscriberPackageVersionID)
+	fmt.Fprintf(out, "Upgrading package %s from %s to %s\n", n.Name, currentNum, n.Version)
+	return false, nil
+}
 
-		if err != nil {
+//installNode installs a single package version, unless it is already installed, and saves it as a dependency
+//in the project file when save is set. It reports whether the package version was installed.
+func installNode(ctx context.Context, org string, n *Node, save bool) (bool, error) {
+	installed, err := isNodeInstalled(ctx, org, n)
+	if err != nil {
+		return false, err
+	}
+
+	if !installed {
+		err = retry(ctx, "Install of package "+n.String(), func() error {
+			req, err := submitInstall(ctx, org, n.ID)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "Submitted install request %s for package: %s - %s\n", req.ID, n, n.ID)
+
+			_, err = waitForInstall(ctx, org, req.ID)
 			return err
+		})
+		if err != nil {
+			return false, err
 		}
 	}
 
-	return nil
+	progress.complete(n.ID)
+
+	if !save {
+		return !installed, nil
+	}
+
+	return !installed, upsertDependencyToProjectFile(ctx, org, n.ID)
 }
 
 //UninstallPackage uninstalls the specified package from the specified org and removes dependencies from the project file
-func UninstallPackage(org string, pkg string) error {
+func UninstallPackage(ctx context.Context, org string, pkg string) error {
 	if err := CheckCli(); err != nil {
 		return err
 	}
@@ -159,25 +326,34 @@ func UninstallPackage(org string, pkg string) error {
 		return err
 	}
 
-	org, err := getOrgUserID(org)
+	org, err := getOrgUserID(ctx, org)
 	if err != nil {
 		return err
 	}
 
-	if !strings.HasPrefix(pkg, versionPrefix) {
-		pkg, err = getPkgVersionID(pkg)
+	// Beta versions can always be uninstalled
+	pkg, err = resolvePkgVersionID(ctx, org, pkg, true)
+	if err != nil {
+		return err
+	}
 
+	// The project file is only edited once the package is gone from the org
+	err = retry(ctx, "Uninstall of package "+pkg, func() error {
+		req, err := submitUninstall(ctx, org, pkg)
 		if err != nil {
 			return err
 		}
-	}
 
-	err = sfdx("force:package:uninstall", "--package", pkg, "-u", org)
+		fmt.Fprintf(out, "Submitted uninstall request %s for package: %s\n", req.ID, pkg)
+
+		_, err = waitForUninstall(ctx, org, req)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
-	err = removeDependencyFromProjectFile(pkg)
+	err = removeDependencyFromProjectFile(ctx, org, pkg)
 	if err != nil {
 		return err
 	}
@@ -204,7 +380,7 @@ func findSfdxProject(path string) (string, error) {
 	return filePath, nil
 }
 
-func getOrgs() error {
+func getOrgs(ctx context.Context) error {
 
 	if len(orgs) > 0 {
 		return nil
@@ -214,7 +390,15 @@ func getOrgs() error {
 		return err
 	}
 
-	jsonBytes, err := sfdxJ("force:org:list")
+	cmds, err := commands()
+	if err != nil {
+		return err
+	}
+
+	listCtx, cancel := withTimeout(ctx, timeouts.List)
+	defer cancel()
+
+	jsonBytes, err := sfdxJ(listCtx, cmds.orgListArgs()...)
 	if err != nil {
 		return err
 	}
@@ -226,27 +410,27 @@ func getOrgs() error {
 		return err
 	}
 
-	orgs = resp.Result.NonScratchOrgs
+	orgs = resp.nonScratchOrgs()
 	scrOrgs = resp.Result.ScratchOrgs
 
 	return nil
 }
 
-func getOrgUserID(alias string) (string, error) {
+func getOrgUserID(ctx context.Context, alias string) (string, error) {
 
 	//already in the username form
 	if strings.Contains(alias, "@") {
 		return alias, nil
 	}
 
-	if err := getOrgs(); err != nil {
+	if err := getOrgs(ctx); err != nil {
 		return "", err
 	}
 
 	isID := strings.HasPrefix(alias, orgPrefix)
 
 	for _, org := range orgs {
-		if isID && org.OrgID == alias {
+		if isID && sameID(org.OrgID, alias) {
 			return org.UserName, nil
 		}
 
@@ -256,7 +440,7 @@ func getOrgUserID(alias string) (string, error) {
 	}
 
 	for _, org := range scrOrgs {
-		if isID && org.OrgID == alias {
+		if isID && sameID(org.OrgID, alias) {
 			return org.UserName, nil
 		}
 
@@ -268,65 +452,115 @@ func getOrgUserID(alias string) (string, error) {
 	return "", errors.New("Failed to locate org with alias: " + alias)
 }
 
-func getPkgVersionID(alias string) (string, error) {
+//getPkgVersionID returns the 04t ID of the dev hub package version matching the reference, or an empty ID when the dev hub does not own the package
+func getPkgVersionID(ctx context.Context, alias string, allowBeta bool) (string, error) {
 
-	if err := getPkgVersions(); err != nil {
+	if err := getPkgVersions(ctx); err != nil {
 		return "", err
 	}
 
-	isID := strings.HasPrefix(alias, packagePrefix)
-	version := "LATEST"
-	versionNum := "0"
-	versionID := ""
+	name, constraint, err := splitPkgReference(alias)
+	if err != nil {
+		return "", err
+	}
 
-	if !isID && strings.Contains(alias, "@") {
-		split := strings.Split("@", alias)
-		version = split[1]
+	pkgID, err := findPackageID(ctx, name)
+	if err != nil || len(pkgID) == 0 {
+		return "", err
 	}
 
+	var candidates []PkgVersion
 	for _, ver := range pkgVersions {
-		if isID && ver.PackageID == alias {
-			return ver.ID, nil
+		if sameID(ver.PackageID, pkgID) {
+			candidates = append(candidates, ver)
 		}
+	}
 
-		if !isID && ver.Name == alias {
-			if version != "LATEST" && ver.Version == version {
-				return ver.ID, nil
-			}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w with alias: %s", ErrPackageNotFound, alias)
+	}
 
-			//Find the latest version
-			// If ver.Version > versionNum
-			if strings.Compare(ver.Version, versionNum) == 1 {
-				versionNum = ver.Version
-				versionID = ver.ID
-			}
+	ver, err := selectPkgVersion(candidates, constraint, allowBeta)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	return ver.ID, nil
+}
+
+//splitPkgReference splits a Name@constraint package reference into its name and version constraint
+func splitPkgReference(ref string) (string, *Constraint, error) {
+	name, version := ref, ""
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		name, version = ref[:i], ref[i+1:]
+	}
+
+	constraint, err := ParseConstraint(version)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return name, constraint, nil
+}
+
+//selectPkgVersion returns the highest of the versions matching the constraint, only considering beta versions when allowed
+func selectPkgVersion(versions []PkgVersion, constraint *Constraint, allowBeta bool) (*PkgVersion, error) {
+	var selected *PkgVersion
+	var selectedNum PackageVersionNumber
+	var betas []string
+
+	for i, ver := range versions {
+		num, err := ver.VersionNumber()
+		if err != nil || !constraint.Matches(num) {
+			continue
+		}
+
+		if !ver.IsReleased && !allowBeta {
+			betas = append(betas, ver.Version)
+			continue
+		}
+
+		if selected == nil || selectedNum.Less(num) {
+			selected = &versions[i]
+			selectedNum = num
 		}
 	}
 
-	if versionID != "" {
-		return versionID, nil
+	if selected == nil && len(betas) > 0 {
+		sortVersions(betas)
+
+		return nil, fmt.Errorf("%w: no released version matches %s, beta versions: %s", ErrBetaOnly, constraint, strings.Join(betas, ", "))
 	}
 
-	return "", errors.New("Failed to locate package version with alias: " + alias)
+	if selected == nil {
+		available := make([]string, 0, len(versions))
+		for _, ver := range versions {
+			available = append(available, ver.Version)
+		}
+		sortVersions(available)
 
+		return nil, fmt.Errorf("%w: no version matches %s, available versions: %s", ErrPackageNotFound, constraint, strings.Join(available, ", "))
+	}
+
+	return selected, nil
 }
 
-func getPkgVersion(ID string) (*PkgVersion, error) {
-	if err := getPkgVersions(); err != nil {
+func getPkgVersion(ctx context.Context, ID string) (*PkgVersion, error) {
+	if err := getPkgVersions(ctx); err != nil {
 		return nil, err
 	}
 
 	for _, ver := range pkgVersions {
-		if ver.ID == ID {
+		if sameID(ver.ID, ID) {
 			return &ver, nil
 		}
 	}
 
-	return nil, errors.New("Failed to locate package version: " + ID)
+	return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, ID)
 }
 
-func getPkgVersions() error {
-	if len(pkgVersions) > 0 {
+func getPkgs(ctx context.Context) error {
+	if len(pkgList) > 0 {
 		return nil
 	}
 
@@ -334,85 +568,75 @@ func getPkgVersions() error {
 		return err
 	}
 
-	jsonBytes, err := sfdxJ("force:package:version:list")
+	cmds, err := commands()
 	if err != nil {
 		return err
 	}
 
-	var resp versionResponse
+	listCtx, cancel := withTimeout(ctx, timeouts.List)
+	defer cancel()
+
+	jsonBytes, err := sfdxJ(listCtx, cmds.packageListArgs()...)
+	if err != nil {
+		return err
+	}
+
+	var resp pkgResponse
 	err = json.Unmarshal(jsonBytes, &resp)
 
 	if err != nil {
 		return err
 	}
 
-	pkgVersions = resp.Result
+	pkgList = resp.Result
 
 	return nil
 }
 
-func getSubscriberPkgVersion(org string, ID string) (*SubscriberPkgVersion, error) {
-	soql := fmt.Sprintf("SELECT Id, SubscriberPackageId, MajorVersion, MinorVersion, PatchVersion, BuildNumber, Package2ContainerOptions, Dependencies FROM SubscriberPackageVersion WHERE Id='%s'", ID)
-
-	jsonBytes, err := sfdxJ("force:data:soql:query", "-u", org, "-t", "-q", soql)
-	if err != nil {
-		return nil, err
-	}
-
-	var response soqlSubscriberPkgVersion
-	err = json.Unmarshal(jsonBytes, &response)
-	if err != nil {
-		return nil, err
+func getPkgVersions(ctx context.Context) error {
+	if len(pkgVersions) > 0 {
+		return nil
 	}
 
-	if response.Result.Size != 1 {
-		panic(fmt.Errorf("More than 1 Subscriber Package Version with ID: %s", ID))
+	if err := CheckCli(); err != nil {
+		return err
 	}
 
-	pkv := response.Result.Records[0]
-
-	pkg, err := getSubscriberPkg(org, pkv.PackageID)
+	cmds, err := commands()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	pkv.Name = pkg.Name
-
-	return &pkv, nil
-}
+	listCtx, cancel := withTimeout(ctx, timeouts.List)
+	defer cancel()
 
-func getSubscriberPkg(org string, ID string) (*SubscriberPkg, error) {
-	soql := fmt.Sprintf("SELECT Name FROM SubscriberPackage WHERE Id='%s'", ID)
-	jsonBytes, err := sfdxJ("force:data:soql:query", "-u", org, "-t", "-q", soql)
+	jsonBytes, err := sfdxJ(listCtx, cmds.packageVersionListArgs()...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var response soqlResult
-	err = json.Unmarshal(jsonBytes, &response)
+	var resp versionResponse
+	err = json.Unmarshal(jsonBytes, &resp)
+
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if response.Result.Size != 1 {
-		panic(fmt.Errorf("More than 1 Subscriber Package with ID: %s", ID))
-	}
+	pkgVersions = resp.Result
 
-	jsonBytes, err = json.Marshal(response.Result.Records[0])
-	if err != nil {
-		return nil, err
-	}
+	return nil
+}
 
-	var pkg SubscriberPkg
-	err = json.Unmarshal(jsonBytes, &pkg)
+func getSubscriberPkgVersion(ctx context.Context, org string, ID string) (*SubscriberPkgVersion, error) {
+	versions, err := getSubscriberPkgVersions(ctx, org, []string{ID})
 	if err != nil {
 		return nil, err
 	}
 
-	return &pkg, nil
+	return versions[ID], nil
 }
 
-func getInstalledPackages(org string) error {
+func getInstalledPackages(ctx context.Context, org string) error {
 	if len(installedPkgs) > 0 {
 		return nil
 	}
@@ -421,7 +645,15 @@ func getInstalledPackages(org string) error {
 		return err
 	}
 
-	jsonBytes, err := sfdxJ("force:package:installed:list", "-u", org)
+	cmds, err := commands()
+	if err != nil {
+		return err
+	}
+
+	listCtx, cancel := withTimeout(ctx, timeouts.List)
+	defer cancel()
+
+	jsonBytes, err := sfdxJ(listCtx, cmds.packageInstalledListArgs(org)...)
 	if err != nil {
 		return err
 	}
@@ -438,146 +670,209 @@ func getInstalledPackages(org string) error {
 	return nil
 }
 
-func isPkgInstalled(org string, pkgVersionID string) bool {
-	if err := getInstalledPackages(org); err != nil {
-		panic(err)
+//installedPackage returns the installed version of the subscriber package in the org, or nil
+func installedPackage(ctx context.Context, org string, subscriberPkgID string) (*InstalledPkg, error) {
+	if err := getInstalledPackages(ctx, org); err != nil {
+		return nil, err
 	}
 
 	for _, pkg := range installedPkgs {
-		if pkg.SubscriberPackageVersionID == pkgVersionID {
-			return true
+		if sameID(pkg.SubscriberPackageID, subscriberPkgID) {
+			return &pkg, nil
 		}
 	}
 
-	return false
+	return nil, nil
 }
 
-func upsertDependencyToProjectFile(org string, pkgVersionID string) error {
+func upsertDependencyToProjectFile(ctx context.Context, org string, pkgVersionID string) error {
 
-	data, err := ioutil.ReadFile(projectPath)
+	proj, err := openProjectFile()
 	if err != nil {
 		return err
 	}
 
-	var proj SfdxProject
-	err = json.Unmarshal(data, &proj)
+	dir, err := proj.selectedPackageDirectory()
 	if err != nil {
 		return err
 	}
 
-	pkgVersion, err := getSubscriberPkgVersion(org, pkgVersionID)
+	pkgVersion, err := getSubscriberPkgVersion(ctx, org, pkgVersionID)
 	if err != nil {
 		return err
 	}
 
-	var pkgDep *SfdxProjectDependency
-	for _, dep := range proj.PackageDirectories[0].Dependencies {
-		if dep.PackageName == pkgVersion.Name {
-			pkgDep = &dep
-			break
-		}
+	if pkgVersion == nil {
+		return fmt.Errorf("%w: %s", ErrPackageNotFound, pkgVersionID)
 	}
 
-	//Only add the dependency if it does not exist
-	if pkgDep == nil {
-		pkgDep = &SfdxProjectDependency{
-			PackageName: pkgVersion.Name,
-		}
+	//Only adds the dependency if it does not exist, an existing one keeps its form unless --pin is given
+	pin := pinDependencies
+	dep := findDependency(dir, pkgVersion.Name)
+	if dep == nil {
+		dep = addDependency(dir, pkgVersion.Name)
+	} else if isPinned(dep) {
+		pin = true
+	}
 
-		proj.PackageDirectories[0].Dependencies = append(proj.PackageDirectories[0].Dependencies, *pkgDep)
+	if err := writeDependency(ctx, proj, dep, pkgVersion, pin); err != nil {
+		return err
 	}
 
-	// We could set unmanaged package versions in the dependency but do we want to?
-	// I envision we could do a dxpm update which would update dependent packages to the latest version anyways
+	if err := checkNameAlias(proj, pkgVersion.Name); err != nil {
+		return err
+	}
 
-	// if pkgVersion.PackageType != managedPackageType {
-	// 	pkgDep.VersionNumber = fmt.Sprintf("%d.%d.%d.%d", pkgVersion.MajorVersion, pkgVersion.MinorVersion, pkgVersion.PatchVersion, pkgVersion.BuildNumber)
-	// 	proj.PackageAliases[pkgDep.PackageName] = pkgVersion.PackageID
-	// }
+	return proj.save()
+}
 
-	proj.PackageAliases[pkgDep.PackageName] = pkgVersionID
+func removeDependencyFromProjectFile(ctx context.Context, org string, pkgVersionID string) error {
 
-	bytes, err := json.MarshalIndent(proj, "", "  ")
+	proj, err := openProjectFile()
 	if err != nil {
 		return err
 	}
 
-	err = ioutil.WriteFile(projectPath, bytes, 0777)
+	dir, err := proj.selectedPackageDirectory()
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func removeDependencyFromProjectFile(pkgVersionID string) error {
-
-	data, err := ioutil.ReadFile(projectPath)
+	name, err := packageName(ctx, org, pkgVersionID)
 	if err != nil {
 		return err
 	}
 
-	var proj SfdxProject
-	err = json.Unmarshal(data, &proj)
+	// Dependencies may name the package or one of its version aliases
+	aliases := proj.packageAliases()
+	removeDependencies(dir, func(dep *jsonObject) bool {
+		pkg := dep.getString("package")
+		return pkg == name || sameID(aliases.getString(pkg), pkgVersionID)
+	})
+
+	// Other package directories may still depend on the package through its aliases
+	pruneAliases(proj, name)
+	for _, key := range append([]string(nil), aliases.keys...) {
+		if sameID(aliases.getString(key), pkgVersionID) {
+			pruneAliases(proj, key)
+		}
+	}
+
+	return proj.save()
+}
+
+//toolingRecords run a SOQL query against the tooling api and decode the records into v
+func toolingRecords(ctx context.Context, org string, soql string, v interface{}) error {
+	records, err := toolingQuery(ctx, org, soql)
 	if err != nil {
 		return err
 	}
 
-	pkgVersion, err := getPkgVersion(pkgVersionID)
+	jsonBytes, err := json.Marshal(records)
 	if err != nil {
 		return err
 	}
 
-	pkgDep := SfdxProjectDependency{
-		PackageName: pkgVersion.Name,
-	}
+	return json.Unmarshal(jsonBytes, v)
+}
 
-	proj.PackageDirectories[0].Dependencies = make([]SfdxProjectDependency, 0, len(proj.PackageDirectories[0].Dependencies))
-	for _, ver := range proj.PackageDirectories[0].Dependencies {
-		if ver.PackageName == pkgDep.PackageName {
-			continue
+//toolingQuery run a SOQL query against the tooling api, directly when the org has an
+//access token and through the cli otherwise or once the token has expired
+func toolingQuery(ctx context.Context, org string, soql string) ([]json.RawMessage, error) {
+	var records []json.RawMessage
+
+	err := retry(ctx, "Query", func() error {
+		var err error
+		records, err = toolingQueryOnce(ctx, org, soql)
+		return err
+	})
+
+	return records, err
+}
+
+func toolingQueryOnce(ctx context.Context, org string, soql string) ([]json.RawMessage, error) {
+	ctx, cancel := withTimeout(ctx, timeouts.Query)
+	defer cancel()
+
+	if client := apiClient(ctx, org); client != nil {
+		records, err := client.ToolingQuery(ctx, soql)
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+			return records, err
 		}
+	}
 
-		proj.PackageDirectories[0].Dependencies = append(proj.PackageDirectories[0].Dependencies, ver)
+	cmds, err := commands()
+	if err != nil {
+		return nil, err
 	}
-	delete(proj.PackageAliases, pkgDep.PackageName)
 
-	bytes, err := json.MarshalIndent(proj, "", "  ")
+	jsonBytes, err := sfdxJ(ctx, cmds.toolingQueryArgs(org, soql)...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = ioutil.WriteFile(projectPath, bytes, 0777)
+	var response soqlResponse
+	err = json.Unmarshal(jsonBytes, &response)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return response.Result.Records, nil
 }
 
-//sfdx run sfdx command with os.Stdout
-func sfdx(arg ...string) error {
-	sfdx := exec.Command("sfdx", arg...)
-	sfdx.Stdout = os.Stdout
-	err := sfdx.Run()
+//sfdx run sfdx command with os.Stdout, decoding failures into an SfdxError
+func sfdx(ctx context.Context, arg ...string) error {
+	err := runner.Run(ctx, arg...)
+	if ctxErr := contextError(ctx, arg); ctxErr != nil {
+		return ctxErr
+	}
+
+	if err != nil {
+		if sfErr := decodeSfdxText(err.Error()); sfErr != nil {
+			return sfErr
+		}
+	}
 
 	return err
 }
 
-//sfdxJ run sfdx command with JSON output
-func sfdxJ(arg ...string) ([]byte, error) {
+//sfdxJ run sfdx command with JSON output, decoding failures into an SfdxError
+func sfdxJ(ctx context.Context, arg ...string) ([]byte, error) {
+	out, err := runner.RunJSON(ctx, arg...)
+	if ctxErr := contextError(ctx, arg); ctxErr != nil {
+		return nil, ctxErr
+	}
 
-	arg = append(arg, "--json")
+	if sfErr := decodeSfdxError(out); sfErr != nil {
+		return nil, sfErr
+	}
 
-	sfdx := exec.Command("sfdx", arg...)
-	buf := new(bytes.Buffer)
-	sfdx.Stdout = buf
+	// Crashes and warnings from the cli or its plugins are not JSON, keep them with the error
+	if msg := bytes.TrimSpace(out); err != nil && len(msg) > 0 && !json.Valid(msg) {
+		if sfErr := decodeSfdxText(string(msg)); sfErr != nil {
+			return nil, sfErr
+		}
 
-	err := sfdx.Run()
+		return nil, fmt.Errorf("%w\n%s", err, msg)
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	return buf.Bytes(), nil
+	return out, nil
+}
+
+//contextError describes why a command was stopped early, or returns nil when it ran to completion
+func contextError(ctx context.Context, arg []string) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return fmt.Errorf("Timed out running: %s: %w", strings.Join(arg, " "), ctx.Err())
+	default:
+		return fmt.Errorf("Cancelled running: %s: %w", strings.Join(arg, " "), ctx.Err())
+	}
 }
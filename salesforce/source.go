@@ -0,0 +1,252 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Source resolves package references to package versions. Each source only knows some
+// packages, such as those owned by the dev hub, and returns an empty ID for the others.
+type Source interface {
+	// String names the source.
+	String() string
+	// Resolve returns the 04t ID of the package version the reference resolves to
+	// for installing into org, or an empty ID when the source does not know the package.
+	Resolve(ctx context.Context, org string, ref string, allowBeta bool) (string, error)
+	// PackageName returns the name of the package of the 04t version ID, or
+	// an empty name when the source does not know the package.
+	PackageName(ctx context.Context, org string, id string) (string, error)
+}
+
+// Sources maps the names used in configuration to the sources package references can be resolved from.
+var Sources = map[string]Source{
+	"aliases": aliasSource{},
+	"devhub":  devHubSource{},
+	"org":     orgSource{},
+}
+
+// sources are tried in order until one knows the package.
+var sources = []Source{aliasSource{}, devHubSource{}, orgSource{}}
+
+// SetSources sets the sources package references are resolved from, tried in order.
+func SetSources(s []Source) {
+	sources = s
+}
+
+// ParseSources converts source names from configuration to their sources.
+func ParseSources(names []string) ([]Source, error) {
+	s := make([]Source, 0, len(names))
+	for _, name := range names {
+		source, ok := Sources[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown resolution source: %s", name)
+		}
+
+		s = append(s, source)
+	}
+
+	return s, nil
+}
+
+// resolvePkgVersionID returns the 04t ID of the package version referenced by ID, packageAliases
+// key or name, from the first source that knows the package.
+func resolvePkgVersionID(ctx context.Context, org string, pkg string, allowBeta bool) (string, error) {
+	if strings.HasPrefix(pkg, versionPrefix) {
+		return pkg, nil
+	}
+
+	var sourceErr error
+	for _, source := range sources {
+		id, err := source.Resolve(ctx, org, pkg, allowBeta)
+		if err != nil && !errors.Is(err, ErrNoDefaultDevHub) {
+			return "", err
+		}
+
+		// Packages may still resolve from another source without a dev hub
+		if err != nil {
+			sourceErr = err
+			continue
+		}
+
+		if len(id) > 0 {
+			return id, nil
+		}
+	}
+
+	return "", packageNotFound(pkg, sourceErr)
+}
+
+// packageName returns the name of the package of the 04t version ID from the first source that knows the package.
+func packageName(ctx context.Context, org string, id string) (string, error) {
+	var sourceErr error
+	for _, source := range sources {
+		name, err := source.PackageName(ctx, org, id)
+		if err != nil {
+			sourceErr = err
+			continue
+		}
+
+		if len(name) > 0 {
+			return name, nil
+		}
+	}
+
+	if sourceErr != nil {
+		return "", sourceErr
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrPackageNotFound, id)
+}
+
+// aliasSource resolves the packageAliases of sfdx-project.json which name a 04t version.
+type aliasSource struct{}
+
+func (aliasSource) String() string {
+	return "aliases"
+}
+
+func (aliasSource) Resolve(ctx context.Context, org string, ref string, allowBeta bool) (string, error) {
+	// Version aliases such as Name@1.2.0-3 name a single version
+	if alias, ok := projectAlias(ref); ok && strings.HasPrefix(alias, versionPrefix) {
+		return alias, nil
+	}
+
+	return "", nil
+}
+
+func (aliasSource) PackageName(ctx context.Context, org string, id string) (string, error) {
+	if len(projectPath) == 0 {
+		return "", nil
+	}
+
+	proj, err := readProject()
+	if err != nil {
+		return "", err
+	}
+
+	for key, alias := range proj.PackageAliases {
+		if alias != id {
+			continue
+		}
+
+		if i := strings.LastIndex(key, "@"); i > 0 {
+			return key[:i], nil
+		}
+
+		return key, nil
+	}
+
+	return "", nil
+}
+
+// devHubSource resolves the package versions owned by the default dev hub.
+type devHubSource struct{}
+
+func (devHubSource) String() string {
+	return "devhub"
+}
+
+func (devHubSource) Resolve(ctx context.Context, org string, ref string, allowBeta bool) (string, error) {
+	return getPkgVersionID(ctx, ref, allowBeta)
+}
+
+func (devHubSource) PackageName(ctx context.Context, org string, id string) (string, error) {
+	ver, err := getPkgVersion(ctx, id)
+	if errors.Is(err, ErrPackageNotFound) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return ver.Name, nil
+}
+
+// orgSource resolves the SubscriberPackageVersions visible to the target org, covering
+// third party packages by their 033 ID or the name of their installed version.
+type orgSource struct{}
+
+func (orgSource) String() string {
+	return "org"
+}
+
+func (orgSource) Resolve(ctx context.Context, org string, ref string, allowBeta bool) (string, error) {
+	name, constraint, err := splitPkgReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	subscriberPkgID := ""
+	if strings.HasPrefix(name, subscriberPrefix) {
+		subscriberPkgID = name
+	} else {
+		if err := getInstalledPackages(ctx, org); err != nil {
+			return "", err
+		}
+
+		for _, pkg := range installedPkgs {
+			if strings.EqualFold(pkg.SubscriberPackageName, name) {
+				subscriberPkgID = pkg.SubscriberPackageID
+				break
+			}
+		}
+	}
+
+	if len(subscriberPkgID) == 0 {
+		return "", nil
+	}
+
+	var versions []SubscriberPkgVersion
+	soql := fmt.Sprintf("%s WHERE SubscriberPackageId = '%s'", subscriberPkgVersionFields, subscriberPkgID)
+	if err := toolingRecords(ctx, org, soql, &versions); err != nil {
+		return "", err
+	}
+
+	var selected *SubscriberPkgVersion
+	var available, betas []string
+	for i, ver := range versions {
+		num := ver.VersionNumber()
+		available = append(available, num.String())
+
+		if !constraint.Matches(num) {
+			continue
+		}
+
+		if ver.IsBeta && !allowBeta {
+			betas = append(betas, num.String())
+			continue
+		}
+
+		if selected == nil || selected.VersionNumber().Less(num) {
+			selected = &versions[i]
+		}
+	}
+
+	if selected != nil {
+		return selected.ID, nil
+	}
+
+	if len(betas) > 0 {
+		sortVersions(betas)
+		return "", fmt.Errorf("%s: %w: no released version matches %s, beta versions: %s", name, ErrBetaOnly, constraint, strings.Join(betas, ", "))
+	}
+
+	sortVersions(available)
+	return "", fmt.Errorf("%s: %w: no version matches %s, available versions: %s", name, ErrPackageNotFound, constraint, strings.Join(available, ", "))
+}
+
+func (orgSource) PackageName(ctx context.Context, org string, id string) (string, error) {
+	pkv, err := getSubscriberPkgVersion(ctx, org, id)
+	if errors.Is(err, ErrPackageNotFound) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return pkv.Name, nil
+}
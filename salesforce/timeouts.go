@@ -0,0 +1,31 @@
+package salesforce
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts limits how long each kind of operation may run.
+// A zero duration never times out.
+type Timeouts struct {
+	Install   time.Duration
+	Uninstall time.Duration
+	Query     time.Duration
+	List      time.Duration
+}
+
+var timeouts Timeouts
+
+// SetTimeouts sets the per operation timeouts used by the salesforce package.
+func SetTimeouts(t Timeouts) {
+	timeouts = t
+}
+
+// withTimeout returns a context that is cancelled after d, or ctx unchanged when d is zero.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, d)
+}
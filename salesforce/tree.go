@@ -0,0 +1,136 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+)
+
+// TreeNode is a package version in a printed dependency tree.
+type TreeNode struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	ID      string `json:"id"`
+	// Installed reports whether this version is installed in the target org, it is only set when an org is given.
+	Installed *bool `json:"installed,omitempty"`
+	// InstalledVersion is the version of the package installed in the target org, if any.
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	// Deduped marks a repeated node, its dependencies are listed where it first appears in the tree.
+	Deduped      bool        `json:"deduped,omitempty"`
+	Dependencies []*TreeNode `json:"dependencies,omitempty"`
+}
+
+// DependencyGraph resolves the dependency graph install would walk for the package, or for
+// every dependency declared by the project when pkg is empty, using the versions locked in
+// dxpm-lock.json. Packages are resolved through org, or the default dev hub when org is empty.
+func DependencyGraph(ctx context.Context, org string, pkg string) (*Graph, error) {
+	if err := CheckCli(); err != nil {
+		return nil, err
+	}
+
+	if err := CheckSFDX(); err != nil {
+		return nil, err
+	}
+
+	org, err := resolveOrg(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []string{pkg}
+	if len(pkg) == 0 {
+		proj, err := readProject()
+		if err != nil {
+			return nil, err
+		}
+
+		refs = projectDependencyRefs(proj)
+		if len(refs) == 0 {
+			return nil, errors.New("The project does not declare any package dependencies")
+		}
+	}
+
+	return lockedGraph(ctx, org, refs, false)
+}
+
+// DependencyTree converts the graph to a tree below each of its roots, listing the dependencies
+// of a package shared by several dependents only where it first appears with its dependencies
+// listed. Dependencies deeper than depth are left out unless depth is 0. When org is given each node records whether it is installed.
+func DependencyTree(ctx context.Context, g *Graph, org string, depth int) ([]*TreeNode, error) {
+	if len(org) > 0 {
+		var err error
+		org, err = getOrgUserID(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// expanded records the level each package's dependencies were listed from
+	expanded := make(map[*Node]int)
+
+	var build func(n *Node, level int) (*TreeNode, error)
+	build = func(n *Node, level int) (*TreeNode, error) {
+		t := &TreeNode{Name: n.Name, Version: n.Version, ID: n.ID}
+
+		if len(org) > 0 && n.Pkg != nil {
+			current, err := installedPackage(ctx, org, n.Pkg.PackageID)
+			if err != nil {
+				return nil, err
+			}
+
+			installed := current != nil && sameID(current.SubscriberPackageVersionID, n.ID)
+			t.Installed = &installed
+			if current != nil {
+				t.InstalledVersion = current.SubscriberPackageVersionNumber
+			}
+		}
+
+		if depth > 0 && level >= depth {
+			return t, nil
+		}
+
+		// Without a depth limit every listing is complete, otherwise only dedupe packages
+		// whose dependencies were listed at least as deep as they would be here
+		if l, ok := expanded[n]; ok && (depth == 0 || l <= level) {
+			t.Deduped = true
+			return t, nil
+		}
+		expanded[n] = level
+
+		for _, dep := range n.Dependencies {
+			child, err := build(dep, level+1)
+			if err != nil {
+				return nil, err
+			}
+
+			t.Dependencies = append(t.Dependencies, child)
+		}
+
+		return t, nil
+	}
+
+	tree := make([]*TreeNode, 0, len(g.Roots))
+	for _, root := range g.Roots {
+		t, err := build(root, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		tree = append(tree, t)
+	}
+
+	return tree, nil
+}
+
+// resolveOrg returns the username of the org, or of the default dev hub when org is empty.
+func resolveOrg(ctx context.Context, org string) (string, error) {
+	if len(org) > 0 {
+		return getOrgUserID(ctx, org)
+	}
+
+	hub, err := DevHub(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return hub.UserName, nil
+}
@@ -0,0 +1,83 @@
+package salesforce
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// treeString formats the tree one node per line, indented by level.
+func treeString(nodes []*TreeNode, indent string) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "%s%s", indent, n.Name)
+		if n.Deduped {
+			b.WriteString(" (deduped)")
+		}
+		b.WriteString("\n")
+		b.WriteString(treeString(n.Dependencies, indent+"  "))
+	}
+
+	return b.String()
+}
+
+func TestDependencyTree(t *testing.T) {
+	// App depends on Core through Lib, Tools depends on Core directly
+	g := NewGraph()
+	app := g.AddRoot(testNode("04tApp", "App", "1.0.0.1", false))
+	tools := g.AddRoot(testNode("04tTools", "Tools", "1.0.0.1", false))
+	lib := g.AddNode(testNode("04tLib", "Lib", "1.0.0.1", false))
+	core := g.AddNode(testNode("04tCore", "Core", "1.0.0.1", false))
+	base := g.AddNode(testNode("04tBase", "Base", "1.0.0.1", false))
+	g.AddEdge(app, lib)
+	g.AddEdge(lib, core)
+	g.AddEdge(tools, core)
+	g.AddEdge(core, base)
+
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{
+			depth: 0,
+			want: `App
+  Lib
+    Core
+      Base
+Tools
+  Core (deduped)
+`,
+		},
+		{
+			// Core's dependencies were cut off below App, so they are listed below Tools
+			depth: 2,
+			want: `App
+  Lib
+    Core
+Tools
+  Core
+    Base
+`,
+		},
+		{
+			depth: 1,
+			want: `App
+  Lib
+Tools
+  Core
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tree, err := DependencyTree(context.Background(), g, "", tt.depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := treeString(tree, ""); got != tt.want {
+			t.Errorf("DependencyTree(depth %d) =\n%s\nwant\n%s", tt.depth, got, tt.want)
+		}
+	}
+}
@@ -2,12 +2,15 @@ package salesforce
 
 // Org represents a Salesforce non-scratch org
 type Org struct {
-	UserName      string
-	OrgID         string `json:"OrgId"`
-	AccessToken   string
-	IsDevHub      bool
-	Alias         string
-	DefaultMarker string
+	UserName                string
+	OrgID                   string `json:"OrgId"`
+	AccessToken             string
+	InstanceURL             string `json:"InstanceUrl"`
+	IsDevHub                bool
+	IsDefaultDevHubUsername bool
+	IsSandbox               bool
+	Alias                   string
+	DefaultMarker           string
 }
 
 // ScratchOrg represents a Salesforce scratch org
@@ -16,6 +19,7 @@ type ScratchOrg struct {
 	OrgID          string `json:"OrgId"`
 	DevHubOrgID    string `json:"DevHubOrgId"`
 	AccessToken    string
+	InstanceURL    string `json:"InstanceUrl"`
 	Alias          string
 	Status         string
 	IsExpired      bool
@@ -27,6 +31,45 @@ type orgListResponse struct {
 	Result struct {
 		NonScratchOrgs []Org
 		ScratchOrgs    []ScratchOrg
+
+		// sf groups non scratch orgs by kind
+		DevHubs   []Org
+		Sandboxes []Org
+		Other     []Org
+	}
+}
+
+// nonScratchOrgs normalizes the sfdx and sf org lists into one
+// list of non scratch orgs, with the default DevHub and sandboxes marked.
+func (r *orgListResponse) nonScratchOrgs() []Org {
+	var all []Org
+	seen := make(map[string]bool)
+
+	sandboxes := make(map[string]bool)
+	for _, org := range r.Result.Sandboxes {
+		sandboxes[org.UserName] = true
 	}
+
+	groups := [][]Org{r.Result.NonScratchOrgs, r.Result.DevHubs, r.Result.Sandboxes, r.Result.Other}
+	for _, group := range groups {
+		for _, org := range group {
+			if seen[org.UserName] {
+				continue
+			}
+			seen[org.UserName] = true
+
+			if sandboxes[org.UserName] {
+				org.IsSandbox = true
+			}
+
+			if org.IsDefaultDevHubUsername && len(org.DefaultMarker) == 0 {
+				org.DefaultMarker = defaultMarker
+			}
+
+			all = append(all, org)
+		}
+	}
+
+	return all
 }
 
@@ -1,9 +1,14 @@
 package salesforce
 
+import "encoding/json"
+
 // Pkg represents a Salesforce package object
 type Pkg struct {
-	Name string
-	ID   string `json:"Id"`
+	Name                string
+	ID                  string `json:"Id"`
+	SubscriberPackageID string `json:"SubscriberPackageId"`
+	NamespacePrefix     string
+	Alias               string
 }
 
 // PkgVersion represents a Salesforce package version
@@ -13,6 +18,12 @@ type PkgVersion struct {
 	PackageID   string `json:"Package2Id"`
 	VersionName string `json:"Name"`
 	Version     string
+	IsReleased  bool
+}
+
+// VersionNumber parses the package version's Version.
+func (p PkgVersion) VersionNumber() (PackageVersionNumber, error) {
+	return ParsePackageVersionNumber(p.Version)
 }
 
 //SubscriberPkgVersion represents a SubscriberPackageVersion object from the tooling api
@@ -25,6 +36,7 @@ type SubscriberPkgVersion struct {
 	PatchVersion int
 	BuildNumber  int
 	PackageType  string `json:"Package2ContainerOptions"`
+	IsBeta       bool
 	Dependencies struct {
 		Ids []struct {
 			SubscriberPackageVersionID string `json:"subscriberPackageVersionId"`
@@ -32,30 +44,38 @@ type SubscriberPkgVersion struct {
 	}
 }
 
-type subscriberPackageDependency struct {
-	SubscriberPackageVersionID string `json:"subscriberPackageVersionId"`
+// VersionNumber returns the subscriber package version's version number.
+func (p *SubscriberPkgVersion) VersionNumber() PackageVersionNumber {
+	return PackageVersionNumber{
+		Major: p.MajorVersion,
+		Minor: p.MinorVersion,
+		Patch: p.PatchVersion,
+		Build: p.BuildNumber,
+	}
 }
 
-type soqlSubscriberPkgVersion struct {
-	Status int
-	Result struct {
-		Size           int
-		EntityTypeName string
-		Records        []SubscriberPkgVersion
-	}
+type subscriberPackageDependency struct {
+	SubscriberPackageVersionID string `json:"subscriberPackageVersionId"`
 }
 
 //SubscriberPkg represents a SubscriberPackage object from the tooling api
 type SubscriberPkg struct {
+	ID   string
 	Name string
 }
 
 //InstalledPkg represents a response item from sfdx force:package:installed:list
 type InstalledPkg struct {
-	ID                         string `json:"Id"`
-	SubscriberPackageID        string `json:"SubscriberPackageId"`
-	SubscriberPackageName      string
-	SubscriberPackageVersionID string `json:"SubscriberPackageVersionId"`
+	ID                             string `json:"Id"`
+	SubscriberPackageID            string `json:"SubscriberPackageId"`
+	SubscriberPackageName          string
+	SubscriberPackageVersionID     string `json:"SubscriberPackageVersionId"`
+	SubscriberPackageVersionNumber string
+}
+
+// VersionNumber parses the installed package's SubscriberPackageVersionNumber.
+func (p InstalledPkg) VersionNumber() (PackageVersionNumber, error) {
+	return ParsePackageVersionNumber(p.SubscriberPackageVersionNumber)
 }
 
 type installedPkgResponse struct {
@@ -63,6 +83,11 @@ type installedPkgResponse struct {
 	Result []InstalledPkg
 }
 
+type uninstallRequestResponse struct {
+	Status int
+	Result PackageUninstallRequest
+}
+
 type pkgResponse struct {
 	Status int
 	Result []Pkg
@@ -73,11 +98,13 @@ type versionResponse struct {
 	Result []PkgVersion
 }
 
-type soqlResult struct {
+// soqlResponse represents the response of a cli SOQL query. The sfdx cli returns
+// the raw tooling api response with size, while sf only returns totalSize.
+type soqlResponse struct {
 	Status int
 	Result struct {
-		Size           int
-		EntityTypeName string
-		Records        []interface{}
+		Size      int
+		TotalSize int
+		Records   []json.RawMessage
 	}
 }
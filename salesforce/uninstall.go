@@ -0,0 +1,126 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	uninstallStatusSuccess = "Success"
+	uninstallStatusError   = "Error"
+)
+
+// PackageUninstallRequest represents a SubscriberPackageVersionUninstallRequest (06y) object tracking an asynchronous package uninstall
+type PackageUninstallRequest struct {
+	ID                         string `json:"Id"`
+	Status                     string
+	SubscriberPackageVersionID string `json:"SubscriberPackageVersionId"`
+}
+
+// Done reports whether the uninstall request has finished, successfully or not.
+func (r *PackageUninstallRequest) Done() bool {
+	return r.Status == uninstallStatusSuccess || r.Status == uninstallStatusError
+}
+
+// Err returns an error when the uninstall request failed, or nil.
+func (r *PackageUninstallRequest) Err() error {
+	if r.Status != uninstallStatusError {
+		return nil
+	}
+
+	return fmt.Errorf("Uninstall request %s failed", r.ID)
+}
+
+// submitUninstall starts the package uninstall without waiting for it to finish.
+func submitUninstall(ctx context.Context, org string, pkg string) (*PackageUninstallRequest, error) {
+	cmds, err := commands()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBytes, err := sfdxJ(ctx, cmds.packageUninstallArgs(org, pkg)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp uninstallRequestResponse
+	err = json.Unmarshal(jsonBytes, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Result.ID) == 0 {
+		return nil, fmt.Errorf("No uninstall request was returned for package: %s", pkg)
+	}
+
+	return &resp.Result, nil
+}
+
+func uninstallReport(ctx context.Context, org string, requestID string) (*PackageUninstallRequest, error) {
+	cmds, err := commands()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBytes, err := sfdxJ(ctx, cmds.packageUninstallReportArgs(org, requestID)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp uninstallRequestResponse
+	err = json.Unmarshal(jsonBytes, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Result, nil
+}
+
+// waitForUninstall polls the uninstall request, printing each change of status, until it
+// succeeds, fails or the uninstall timeout passes.
+func waitForUninstall(ctx context.Context, org string, req *PackageUninstallRequest) (*PackageUninstallRequest, error) {
+	waitCtx, cancel := withTimeout(ctx, timeouts.Uninstall)
+	defer cancel()
+
+	start := time.Now()
+	requestID := req.ID
+	status := ""
+
+	for {
+		if req.Status != status {
+			status = req.Status
+			fmt.Fprintf(out, "Uninstall request %s: %s (%s)\n", requestID, status, time.Since(start).Round(time.Second))
+		}
+
+		if req.Done() {
+			return req, req.Err()
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() == nil {
+				return nil, uninstallStillRunning(requestID)
+			}
+
+			return nil, fmt.Errorf("Stopped waiting for uninstall request %s: %w", requestID, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+
+		var err error
+		req, err = uninstallReport(waitCtx, org, requestID)
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return nil, uninstallStillRunning(requestID)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func uninstallStillRunning(requestID string) error {
+	return fmt.Errorf("Uninstall request %s is still running after %s", requestID, timeouts.Uninstall)
+}
@@ -0,0 +1,268 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severity is how serious a validation issue is, only errors fail validation.
+type Severity string
+
+// Severities
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Validation checks
+const (
+	// CheckMissingAlias reports a dependency without a packageAliases entry.
+	CheckMissingAlias = "missing-alias"
+	// CheckUnusedAlias reports a packageAliases entry no dependency or package directory references.
+	CheckUnusedAlias = "unused-alias"
+	// CheckAliasType reports an alias naming a 0Ho package where a 04t version is expected, or the reverse.
+	CheckAliasType = "alias-type"
+	// CheckDuplicatePath reports package directories sharing a path.
+	CheckDuplicatePath = "duplicate-path"
+	// CheckCycle reports dependencies forming a cycle across package directories.
+	CheckCycle = "cycle"
+	// CheckMissingPath reports a package directory path missing on disk.
+	CheckMissingPath = "missing-path"
+	// CheckUnknownVersion reports a dependency versionNumber matching no version in the dev hub.
+	CheckUnknownVersion = "unknown-version"
+)
+
+// Issue is a problem found in sfdx-project.json.
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Check    string   `json:"check"`
+	Message  string   `json:"message"`
+}
+
+// Validation lists the issues found in a project file.
+type Validation struct {
+	Project string  `json:"project"`
+	Issues  []Issue `json:"issues"`
+}
+
+// Errors counts the issues with error severity.
+func (v *Validation) Errors() int {
+	return v.count(SeverityError)
+}
+
+// Warnings counts the issues with warning severity.
+func (v *Validation) Warnings() int {
+	return v.count(SeverityWarning)
+}
+
+func (v *Validation) count(severity Severity) int {
+	n := 0
+	for _, issue := range v.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (v *Validation) add(severity Severity, check string, format string, a ...interface{}) {
+	v.Issues = append(v.Issues, Issue{Severity: severity, Check: check, Message: fmt.Sprintf(format, a...)})
+}
+
+// ValidateProject checks that the located sfdx-project.json is coherent: every dependency has a
+// packageAliases entry of the right type, every alias is referenced, package directory paths are
+// distinct and exist, dependencies across package directories do not form a cycle, and every
+// dependency versionNumber exists in the default dev hub. The dev hub is only queried when a
+// dependency declares a versionNumber.
+func ValidateProject(ctx context.Context) (*Validation, error) {
+	if err := CheckSFDX(); err != nil {
+		return nil, err
+	}
+
+	proj, err := readProject()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Validation{Project: projectPath, Issues: []Issue{}}
+
+	validatePaths(v, proj)
+	validateAliases(v, proj)
+
+	g, err := ProjectGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	var cycle *CycleError
+	if _, err := g.InstallOrder(); errors.As(err, &cycle) {
+		v.add(SeverityError, CheckCycle, "%v", cycle)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := validateVersionNumbers(ctx, v, proj); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// validatePaths reports package directories sharing a path and paths missing on disk.
+func validatePaths(v *Validation, proj *SfdxProject) {
+	root := filepath.Dir(projectPath)
+	seen := make(map[string]bool)
+
+	for _, dir := range proj.PackageDirectories {
+		path := filepath.Clean(dir.Path)
+		if seen[path] {
+			v.add(SeverityError, CheckDuplicatePath, "packageDirectory %s is declared more than once", dir.Path)
+			continue
+		}
+		seen[path] = true
+
+		info, err := os.Stat(filepath.Join(root, path))
+		if err != nil || !info.IsDir() {
+			v.add(SeverityError, CheckMissingPath, "packageDirectory %s does not exist", dir.Path)
+		}
+	}
+}
+
+// validateAliases reports dependencies without an alias, aliases of the wrong type and unreferenced aliases.
+func validateAliases(v *Validation, proj *SfdxProject) {
+	own := make(map[string]bool)
+	for _, dir := range proj.PackageDirectories {
+		if len(dir.PackageName) > 0 {
+			own[dir.PackageName] = true
+		}
+	}
+
+	referenced := make(map[string]bool)
+	for _, dir := range proj.PackageDirectories {
+		referenced[dir.PackageName] = true
+
+		for _, dep := range dir.Dependencies {
+			name := dep.PackageName
+			referenced[name] = true
+
+			if strings.HasPrefix(name, versionPrefix) || strings.HasPrefix(name, packagePrefix) {
+				continue
+			}
+
+			alias, ok := proj.PackageAliases[name]
+			switch {
+			case !ok:
+				// Packages of the project itself are resolved from their packageDirectory
+				if !own[name] {
+					v.add(SeverityError, CheckMissingAlias, "packageDirectory %s: dependency %s has no packageAliases entry", dir.Path, name)
+				}
+			case len(dep.VersionNumber) > 0 && !strings.HasPrefix(alias, packagePrefix):
+				v.add(SeverityError, CheckAliasType, "packageDirectory %s: dependency %s declares versionNumber %s but its alias %s is not a 0Ho package ID", dir.Path, name, dep.VersionNumber, alias)
+			case len(dep.VersionNumber) == 0 && strings.HasPrefix(alias, packagePrefix):
+				v.add(SeverityError, CheckAliasType, "packageDirectory %s: dependency %s has no versionNumber but its alias %s is a 0Ho package ID", dir.Path, name, alias)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(proj.PackageAliases))
+	for key := range proj.PackageAliases {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		alias := proj.PackageAliases[key]
+		name, isVersion := key, false
+		if i := strings.LastIndex(key, "@"); i > 0 {
+			name, isVersion = key[:i], true
+		}
+
+		switch {
+		case !strings.HasPrefix(alias, packagePrefix) && !strings.HasPrefix(alias, versionPrefix):
+			v.add(SeverityError, CheckAliasType, "alias %s: %s is not a 0Ho package or 04t package version ID", key, alias)
+		case isVersion && !strings.HasPrefix(alias, versionPrefix):
+			v.add(SeverityError, CheckAliasType, "alias %s names a package version but %s is not a 04t package version ID", key, alias)
+		case own[key] && !strings.HasPrefix(alias, packagePrefix):
+			v.add(SeverityError, CheckAliasType, "alias %s names a project package but %s is not a 0Ho package ID", key, alias)
+		}
+
+		// Version aliases of referenced packages are kept for sfdx, as dxpm pin writes them
+		if !referenced[key] && !(isVersion && referenced[name]) {
+			v.add(SeverityWarning, CheckUnusedAlias, "alias %s is not referenced by any dependency or packageDirectory", key)
+		}
+	}
+}
+
+// validateVersionNumbers reports dependency versionNumbers matching no version of the dev hub package.
+func validateVersionNumbers(ctx context.Context, v *Validation, proj *SfdxProject) error {
+	var deps []SfdxProjectDependency
+	var dirs []string
+	for _, dir := range proj.PackageDirectories {
+		for _, dep := range dir.Dependencies {
+			if len(dep.VersionNumber) > 0 {
+				deps = append(deps, dep)
+				dirs = append(dirs, dir.Path)
+			}
+		}
+	}
+
+	if len(deps) == 0 {
+		return nil
+	}
+
+	if err := getPkgVersions(ctx); errors.Is(err, ErrNoDefaultDevHub) {
+		v.add(SeverityWarning, CheckUnknownVersion, "versionNumbers were not checked: %v", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for i, dep := range deps {
+		constraint, err := ParseConstraint(dep.VersionNumber)
+		if err != nil {
+			v.add(SeverityError, CheckUnknownVersion, "packageDirectory %s: dependency %s: %v", dirs[i], dep.PackageName, err)
+			continue
+		}
+
+		packageID := dep.PackageName
+		if alias, ok := proj.PackageAliases[dep.PackageName]; ok {
+			packageID = alias
+		}
+
+		var available []string
+		found := false
+		for _, ver := range pkgVersions {
+			if strings.HasPrefix(packageID, packagePrefix) && !sameID(ver.PackageID, packageID) {
+				continue
+			}
+
+			if !strings.HasPrefix(packageID, packagePrefix) && !strings.EqualFold(ver.Name, dep.PackageName) {
+				continue
+			}
+
+			num, err := ver.VersionNumber()
+			if err != nil {
+				continue
+			}
+
+			available = append(available, num.String())
+			found = found || constraint.Matches(num)
+		}
+
+		// Packages the dev hub does not own cannot be checked
+		if found || len(available) == 0 {
+			continue
+		}
+
+		sortVersions(available)
+		v.add(SeverityError, CheckUnknownVersion, "packageDirectory %s: dependency %s versionNumber %s does not exist in the dev hub, available versions: %s", dirs[i], dep.PackageName, dep.VersionNumber, strings.Join(available, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package salesforce
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestValidateProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		dirs    []string
+		devHub  bool
+		want    []string
+	}{
+		{
+			name: "valid",
+			project: `{"packageDirectories": [
+				{"path": "core", "package": "App", "versionNumber": "1.0.0.NEXT", "dependencies": [
+					{"package": "Core", "versionNumber": "1.0.0.LATEST"},
+					{"package": "Partner@2.1.0-3"},
+					{"package": "Utils"}
+				]},
+				{"path": "extra", "dependencies": [{"package": "App", "versionNumber": "1.0.0.LATEST"}, {"package": "04t000000000005AAA"}]}
+			], "packageAliases": {
+				"App": "0Ho000000000009AAA",
+				"Core": "0Ho000000000001AAA",
+				"Core@1.0.0-1": "04t000000000001AAA",
+				"Partner@2.1.0-3": "04t000000000003AAA",
+				"Utils": "04t000000000004AAA"
+			}}`,
+			dirs:   []string{"core", "extra"},
+			devHub: true,
+		},
+		{
+			name: "missing alias",
+			project: `{"packageDirectories": [
+				{"path": "core", "package": "Core", "dependencies": [{"package": "Base"}]},
+				{"path": "app", "dependencies": [{"package": "Core"}]}
+			]}`,
+			dirs: []string{"core", "app"},
+			want: []string{"error missing-alias"},
+		},
+		{
+			name: "unused alias",
+			project: `{"packageDirectories": [{"path": "core", "dependencies": [{"package": "Base"}]}], "packageAliases": {
+				"Base": "04t000000000001AAA",
+				"Base@1.0.0-1": "04t000000000001AAA",
+				"Old": "04t000000000002AAA",
+				"Old@1.0.0-1": "04t000000000002AAA"
+			}}`,
+			dirs: []string{"core"},
+			want: []string{"warning unused-alias", "warning unused-alias"},
+		},
+		{
+			name: "alias type",
+			project: `{"packageDirectories": [
+				{"path": "core", "package": "Core", "dependencies": [
+					{"package": "Base", "versionNumber": "1.0.0.LATEST"},
+					{"package": "Utils"},
+					{"package": "Bad"},
+					{"package": "Utils@1.0.0-1"}
+				]}
+			], "packageAliases": {
+				"Base": "04t000000000001AAA",
+				"Utils": "0Ho000000000002AAA",
+				"Utils@1.0.0-1": "0Ho000000000002AAA",
+				"Bad": "not an ID",
+				"Core": "04t000000000003AAA"
+			}}`,
+			dirs:   []string{"core"},
+			devHub: true,
+			want:   []string{"error alias-type", "error alias-type", "error alias-type", "error alias-type", "error alias-type", "error alias-type"},
+		},
+		{
+			name: "duplicate path",
+			project: `{"packageDirectories": [
+				{"path": "core"},
+				{"path": "./core/"}
+			]}`,
+			dirs: []string{"core"},
+			want: []string{"error duplicate-path"},
+		},
+		{
+			name:    "missing path",
+			project: `{"packageDirectories": [{"path": "core"}, {"path": "app"}]}`,
+			dirs:    []string{"core"},
+			want:    []string{"error missing-path"},
+		},
+		{
+			name: "unknown version",
+			project: `{"packageDirectories": [
+				{"path": "core", "dependencies": [
+					{"package": "Core", "versionNumber": "1.0.0.LATEST"},
+					{"package": "Core", "versionNumber": "1.0.0.2"},
+					{"package": "Billing", "versionNumber": "2.0.0.LATEST"},
+					{"package": "Partner", "versionNumber": "3.0.0.LATEST"},
+					{"package": "Reports", "versionNumber": "1.abc"}
+				]}
+			], "packageAliases": {
+				"Core": "0Ho000000000001AAA",
+				"Billing": "0Ho000000000002AAA",
+				"Partner": "0Ho000000000008AAA",
+				"Reports": "0Ho000000000003AAA"
+			}}`,
+			dirs:   []string{"core"},
+			devHub: true,
+			want:   []string{"error unknown-version", "error unknown-version", "error unknown-version"},
+		},
+		{
+			name: "no dev hub",
+			project: `{"packageDirectories": [
+				{"path": "core", "dependencies": [{"package": "Core", "versionNumber": "9.0.0.LATEST"}]}
+			], "packageAliases": {"Core": "0Ho000000000001AAA"}}`,
+			dirs: []string{"core"},
+			want: []string{"warning unknown-version"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useLockTestRunner(t, lockTestRunner())
+			if tt.devHub {
+				usePackageLists(t)
+				pkgVersions = append(pkgVersions, PkgVersion{Name: "Billing", ID: "04t000000000002AAA", PackageID: "0Ho000000000002AAA", Version: "1.0.0.1", IsReleased: true})
+			}
+
+			path := useTestProject(t, tt.project)
+			for _, dir := range tt.dirs {
+				if err := os.Mkdir(filepath.Join(filepath.Dir(path), dir), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			v, err := ValidateProject(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, issue := range v.Issues {
+				got = append(got, string(issue.Severity)+" "+issue.Check)
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateProject() issues = %+v, want %v", v.Issues, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateProjectCycle(t *testing.T) {
+	useTestProject(t, `{"packageDirectories": [
+		{"path": ".", "package": "Core", "versionNumber": "1.2.0.NEXT", "dependencies": [{"package": "App@1.0.0-1"}]},
+		{"path": "./", "package": "App", "versionNumber": "1.0.0.NEXT", "dependencies": [{"package": "Core"}]}
+	], "packageAliases": {"Core": "0Ho000000000001AAA", "App": "0Ho000000000002AAA", "App@1.0.0-1": "04t000000000002AAA"}}`)
+
+	v, err := ValidateProject(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, issue := range v.Issues {
+		found = found || issue.Check == CheckCycle
+	}
+
+	if !found {
+		t.Errorf("ValidateProject() issues = %+v, want a %s issue", v.Issues, CheckCycle)
+	}
+}
@@ -0,0 +1,112 @@
+package salesforce
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PackageVersionNumber is a four part Major.Minor.Patch.Build package version number.
+type PackageVersionNumber struct {
+	Major int
+	Minor int
+	Patch int
+	Build int
+}
+
+// ParsePackageVersionNumber parses a complete Major.Minor.Patch.Build version
+// number, or the sfdx alias form Major.Minor.Patch-Build.
+func ParsePackageVersionNumber(s string) (PackageVersionNumber, error) {
+	v, n, err := parseVersionParts(s)
+	if err != nil {
+		return v, err
+	}
+
+	if n < 4 {
+		return v, fmt.Errorf("Incomplete version number: %s", s)
+	}
+
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or higher than o.
+func (v PackageVersionNumber) Compare(o PackageVersionNumber) int {
+	a, b := v.parts(), o.parts()
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		}
+
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Less reports whether v is lower than o.
+func (v PackageVersionNumber) Less(o PackageVersionNumber) bool {
+	return v.Compare(o) < 0
+}
+
+// Equal reports whether v and o are the same version.
+func (v PackageVersionNumber) Equal(o PackageVersionNumber) bool {
+	return v == o
+}
+
+// String formats the version as Major.Minor.Patch.Build.
+func (v PackageVersionNumber) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Patch, v.Build)
+}
+
+func (v PackageVersionNumber) parts() [4]int {
+	return [4]int{v.Major, v.Minor, v.Patch, v.Build}
+}
+
+func versionFromParts(p [4]int) PackageVersionNumber {
+	return PackageVersionNumber{Major: p[0], Minor: p[1], Patch: p[2], Build: p[3]}
+}
+
+// sortVersions sorts version number strings from lowest to highest, unparsable versions first.
+func sortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, _, _ := parseVersionParts(versions[i])
+		b, _, _ := parseVersionParts(versions[j])
+		return a.Less(b)
+	})
+}
+
+// parseVersionParts parses a Major.Minor.Patch.Build version, or an sfdx style Major.Minor.Patch-Build.
+// Parsing stops at the first missing or wildcard (x, *, LATEST, NEXT) part, returning the
+// number of parts given with every following part zero.
+func parseVersionParts(s string) (PackageVersionNumber, int, error) {
+	var p [4]int
+
+	s = strings.Replace(strings.TrimSpace(s), "-", ".", 1)
+	if len(s) == 0 {
+		return versionFromParts(p), 0, fmt.Errorf("missing version number")
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 4 {
+		return versionFromParts(p), 0, fmt.Errorf("too many parts in version number %s", s)
+	}
+
+	for i, part := range parts {
+		switch strings.ToUpper(part) {
+		case "X", "*", latestVersion, "NEXT":
+			return versionFromParts(p), i, nil
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return versionFromParts(p), 0, fmt.Errorf("invalid version number %s", s)
+		}
+
+		p[i] = n
+	}
+
+	return versionFromParts(p), len(parts), nil
+}
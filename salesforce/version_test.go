@@ -0,0 +1,76 @@
+package salesforce
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePackageVersionNumber(t *testing.T) {
+	tests := []struct {
+		s    string
+		want PackageVersionNumber
+	}{
+		{"1.2.3.4", PackageVersionNumber{1, 2, 3, 4}},
+		{"1.2.3-4", PackageVersionNumber{1, 2, 3, 4}},
+		{" 10.0.12.140 ", PackageVersionNumber{10, 0, 12, 140}},
+		{"0.0.0.0", PackageVersionNumber{}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePackageVersionNumber(tt.s)
+		if err != nil {
+			t.Errorf("ParsePackageVersionNumber(%q) error = %v", tt.s, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("ParsePackageVersionNumber(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+
+	for _, s := range []string{"", "1.2.3", "1.2.3.NEXT", "1.2.3.4.5", "1.2.3.-4", "a.b.c.d", "1.2.3-4-5"} {
+		if got, err := ParsePackageVersionNumber(s); err == nil {
+			t.Errorf("ParsePackageVersionNumber(%q) = %v, want an error", s, got)
+		}
+	}
+}
+
+func TestPackageVersionNumberCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3.4", "1.2.3.4", 0},
+		{"1.2.3.4", "1.2.3.5", -1},
+		{"1.2.4.0", "1.2.3.9", 1},
+		{"1.10.0.0", "1.9.0.0", 1},
+		{"2.0.0.0", "10.0.0.0", -1},
+		{"0.1.0.0", "0.0.9.9", 1},
+	}
+
+	for _, tt := range tests {
+		a, b := mustParseVersion(t, tt.a), mustParseVersion(t, tt.b)
+
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+
+		if got := a.Less(b); got != (tt.want < 0) {
+			t.Errorf("%s.Less(%s) = %t, want %t", tt.a, tt.b, got, tt.want < 0)
+		}
+
+		if got := a.Equal(b); got != (tt.want == 0) {
+			t.Errorf("%s.Equal(%s) = %t, want %t", tt.a, tt.b, got, tt.want == 0)
+		}
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	versions := []string{"1.10.0.1", "1.2.0.3", "bad", "1.2.0-10", "0.9.0.0", "1.2.0.9"}
+	sortVersions(versions)
+
+	want := []string{"bad", "0.9.0.0", "1.2.0.3", "1.2.0.9", "1.2.0-10", "1.10.0.1"}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("sortVersions() = %v, want %v", versions, want)
+	}
+}
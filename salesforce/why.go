@@ -0,0 +1,151 @@
+package salesforce
+
+import (
+	"context"
+	"strings"
+)
+
+// PackageMatcher returns a function reporting whether a node is a version of the referenced
+// package, given by name, 0Ho package ID, 033 subscriber package ID or 04t version ID.
+func PackageMatcher(ctx context.Context, ref string) (func(n *Node) bool, error) {
+	switch {
+	case strings.HasPrefix(ref, versionPrefix):
+		return func(n *Node) bool {
+			return sameID(n.ID, ref)
+		}, nil
+	case strings.HasPrefix(ref, subscriberPrefix):
+		return func(n *Node) bool {
+			return n.Pkg != nil && sameID(n.Pkg.PackageID, ref)
+		}, nil
+	case strings.HasPrefix(ref, packagePrefix):
+		// Only the dev hub knows which versions belong to a 0Ho package
+		if err := getPkgVersions(ctx); err != nil {
+			return nil, err
+		}
+
+		ids := make(map[string]bool)
+		for _, ver := range pkgVersions {
+			if sameID(ver.PackageID, ref) {
+				ids[ver.ID] = true
+			}
+		}
+
+		return func(n *Node) bool {
+			return ids[n.ID]
+		}, nil
+	default:
+		return func(n *Node) bool {
+			return strings.EqualFold(n.Name, ref)
+		}, nil
+	}
+}
+
+// Paths returns every dependency path from one of the graph's roots down to a node matching
+// match. Each path starts with a root and ends with the matching node.
+func (g *Graph) Paths(match func(n *Node) bool) [][]*Node {
+	var paths [][]*Node
+	var path []*Node
+
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		for _, p := range path {
+			if p == n {
+				return
+			}
+		}
+
+		path = append(path, n)
+		if match(n) {
+			paths = append(paths, append([]*Node(nil), path...))
+		} else {
+			for _, dep := range n.Dependencies {
+				visit(dep)
+			}
+		}
+		path = path[:len(path)-1]
+	}
+
+	for _, root := range g.Roots {
+		visit(root)
+	}
+
+	return paths
+}
+
+// InstalledGraph builds the dependency graph of the packages installed in the org. Its roots
+// are the installed packages that no other package in the graph depends on. Packages declare
+// the minimum version of each dependency they were built against, so each dependency points
+// at the installed version of the same package, or at the declared version when the package
+// is missing from the org.
+func InstalledGraph(ctx context.Context, org string) (*Graph, error) {
+	if err := CheckCli(); err != nil {
+		return nil, err
+	}
+
+	org, err := getOrgUserID(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := getInstalledPackages(ctx, org); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(installedPkgs))
+	for _, pkg := range installedPkgs {
+		ids = append(ids, pkg.SubscriberPackageVersionID)
+	}
+
+	versions, err := getSubscriberPkgVersions(ctx, org, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var depIDs []string
+	for _, id := range ids {
+		for _, dep := range versions[id].Dependencies.Ids {
+			if !containsID(depIDs, dep.SubscriberPackageVersionID) {
+				depIDs = append(depIDs, dep.SubscriberPackageVersionID)
+			}
+		}
+	}
+
+	deps, err := getSubscriberPkgVersions(ctx, org, depIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewGraph()
+	node := func(pkv *SubscriberPkgVersion) *Node {
+		return g.AddNode(&Node{ID: pkv.ID, Name: pkv.Name, Version: pkv.VersionNumber().String(), Pkg: pkv})
+	}
+
+	// Keyed by subscriber package
+	installed := make(map[string]*Node)
+	for _, id := range ids {
+		installed[shortID(versions[id].PackageID)] = node(versions[id])
+	}
+
+	for _, id := range ids {
+		n := installed[shortID(versions[id].PackageID)]
+
+		for _, dep := range versions[id].Dependencies.Ids {
+			declared := deps[dep.SubscriberPackageVersionID]
+
+			target, ok := installed[shortID(declared.PackageID)]
+			if !ok {
+				target = node(declared)
+			}
+
+			g.AddEdge(n, target)
+		}
+	}
+
+	for _, n := range g.Nodes() {
+		if len(n.Dependents) == 0 {
+			g.AddRoot(n)
+		}
+	}
+
+	return g, nil
+}
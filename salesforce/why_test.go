@@ -0,0 +1,50 @@
+package salesforce
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInstalledGraph(t *testing.T) {
+	const query = "data query --target-org user1@example.com --use-tooling-api --query "
+
+	// App 1.0 was built against Base 1.0 and Core 1.0, the org has Base 1.3 installed and no Core
+	useLockTestRunner(t, &stubRunner{outputs: map[string]string{
+		"package installed list --target-org user1@example.com": `{"status":0,"result":[
+			{"SubscriberPackageId":"033000000000001AAA","SubscriberPackageName":"Base","SubscriberPackageVersionId":"04t000000000013AAA","SubscriberPackageVersionNumber":"1.3.0.1"},
+			{"SubscriberPackageId":"033000000000002AAA","SubscriberPackageName":"App","SubscriberPackageVersionId":"04t000000000020AAA","SubscriberPackageVersionNumber":"1.0.0.1"}]}`,
+		query + subscriberPkgVersionFields + " WHERE Id IN ('04t000000000013AAA','04t000000000020AAA')": `{"status":0,"result":{"done":true,"records":[
+			{"Id":"04t000000000013AAA","SubscriberPackageId":"033000000000001AAA","MajorVersion":1,"MinorVersion":3,"PatchVersion":0,"BuildNumber":1},
+			{"Id":"04t000000000020AAA","SubscriberPackageId":"033000000000002AAA","MajorVersion":1,"MinorVersion":0,"PatchVersion":0,"BuildNumber":1,
+			 "Dependencies":{"ids":[{"subscriberPackageVersionId":"04t000000000010AAA"},{"subscriberPackageVersionId":"04t000000000030AAA"}]}}]}}`,
+		query + "SELECT Id, Name FROM SubscriberPackage WHERE Id IN ('033000000000001AAA','033000000000002AAA')": `{"status":0,"result":{"done":true,"records":[
+			{"Id":"033000000000001AAA","Name":"Base"},{"Id":"033000000000002AAA","Name":"App"}]}}`,
+		query + subscriberPkgVersionFields + " WHERE Id IN ('04t000000000010AAA','04t000000000030AAA')": `{"status":0,"result":{"done":true,"records":[
+			{"Id":"04t000000000010AAA","SubscriberPackageId":"033000000000001AAA","MajorVersion":1,"MinorVersion":0,"PatchVersion":0,"BuildNumber":1},
+			{"Id":"04t000000000030AAA","SubscriberPackageId":"033000000000003AAA","MajorVersion":1,"MinorVersion":0,"PatchVersion":0,"BuildNumber":1}]}}`,
+		query + "SELECT Id, Name FROM SubscriberPackage WHERE Id IN ('033000000000001AAA','033000000000003AAA')": `{"status":0,"result":{"done":true,"records":[
+			{"Id":"033000000000001AAA","Name":"Base"},{"Id":"033000000000003AAA","Name":"Core"}]}}`,
+	}})
+
+	g, err := InstalledGraph(context.Background(), "user1@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := nodeIDs(g); got != "04t000000000013AAA,04t000000000020AAA,04t000000000030AAA" {
+		t.Errorf("nodes = %s, want installed Base and App, and the declared Core", got)
+	}
+
+	if len(g.Roots) != 1 || g.Roots[0].Name != "App" {
+		t.Fatalf("roots = %v, want App", g.Roots)
+	}
+
+	var deps []string
+	for _, dep := range g.Roots[0].Dependencies {
+		deps = append(deps, dep.String())
+	}
+
+	if len(deps) != 2 || deps[0] != "Base 1.3.0.1" || deps[1] != "Core 1.0.0.1" {
+		t.Errorf("App dependencies = %v, want [Base 1.3.0.1 Core 1.0.0.1]", deps)
+	}
+}